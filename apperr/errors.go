@@ -0,0 +1,59 @@
+package apperr
+
+import "errors"
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrConflict      = errors.New("conflict")
+	ErrNoCandidate   = errors.New("no candidate")
+	ErrValidation    = errors.New("validation failed")
+)
+
+// Error — доменная ошибка: Kind определяет HTTP-статус, Code и Message
+// уходят клиенту в errorResponse как есть.
+type Error struct {
+	Kind    error
+	Code    string
+	Message string
+	Details []string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+func New(kind error, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(message string) *Error {
+	return New(ErrNotFound, "NOT_FOUND", message)
+}
+
+func AlreadyExists(code, message string) *Error {
+	return New(ErrAlreadyExists, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(ErrConflict, code, message)
+}
+
+func NoCandidate(code, message string) *Error {
+	return New(ErrNoCandidate, code, message)
+}
+
+func Validation(message string) *Error {
+	return New(ErrValidation, "INVALID_INPUT", message)
+}
+
+// WithDetails возвращает копию ошибки с дополнительными подробностями.
+func (e *Error) WithDetails(details ...string) *Error {
+	c := *e
+	c.Details = append(append([]string(nil), e.Details...), details...)
+	return &c
+}
@@ -6,8 +6,6 @@ import (
 	"os"
 	"time"
 
-	"PR/models"
-
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -40,6 +38,16 @@ func (c *DatabaseConfig) GetDSN(dbname string) string {
 		c.Host, c.User, c.Password, dbname, c.Port, c.SSLMode)
 }
 
+func StorageDriver() string {
+	return getEnv("STORAGE_DRIVER", "postgres")
+}
+
+// MigrateOnStart разрешает применять миграции при запуске сервиса.
+// Без него сервис отказывается стартовать на немигрированной схеме.
+func MigrateOnStart() bool {
+	return getEnv("MIGRATE_ON_START", "false") == "true"
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -50,7 +58,7 @@ func getEnv(key, defaultValue string) string {
 func InitDB() (*gorm.DB, error) {
 	config := NewDatabaseConfig()
 
-	db, err := gorm.Open(postgres.Open(config.GetDSN("")))
+	db, err := gorm.Open(postgres.Open(config.GetDSN("")), gormConfig())
 	if err != nil {
 		log.Printf("База данных %s не существует, пытаемся создать...", config.DBName)
 
@@ -73,7 +81,7 @@ func InitDB() (*gorm.DB, error) {
 		sqlTempDB, _ := tempDB.DB()
 		sqlTempDB.Close()
 
-		db, err = gorm.Open(postgres.Open(config.GetDSN("")))
+		db, err = gorm.Open(postgres.Open(config.GetDSN("")), gormConfig())
 		if err != nil {
 			return nil, fmt.Errorf("не удалось подключиться к новой базе данных: %w", err)
 		}
@@ -90,18 +98,14 @@ func InitDB() (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	if err := db.AutoMigrate(
-		&models.Team{},
-		&models.User{},
-		&models.PullRequest{},
-	); err != nil {
-		return nil, fmt.Errorf("ошибка миграции базы данных: %w", err)
-	}
-
-	log.Println("Успешное подключение к БД и миграция")
+	log.Println("Успешное подключение к БД")
 	return db, nil
 }
 
+func gormConfig() *gorm.Config {
+	return &gorm.Config{TranslateError: true}
+}
+
 func isDatabaseExistsError(err error) bool {
 	if err == nil {
 		return false
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// RandomSeed возвращает seed для выбора ревьюеров из REVIEW_RANDOM_SEED.
+// Если переменная не задана, ok == false и выбор инициализируется временем.
+func RandomSeed() (seed int64, ok bool, err error) {
+	value := os.Getenv("REVIEW_RANDOM_SEED")
+	if value == "" {
+		return 0, false, nil
+	}
+
+	seed, err = strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("REVIEW_RANDOM_SEED must be an integer: %w", err)
+	}
+	return seed, true, nil
+}
+
+// IdempotencyTTL — сколько хранится ответ на запрос с Idempotency-Key (IDEMPOTENCY_TTL, по умолчанию 24h).
+func IdempotencyTTL() (time.Duration, error) {
+	ttl, err := time.ParseDuration(getEnv("IDEMPOTENCY_TTL", "24h"))
+	if err != nil || ttl <= 0 {
+		return 0, fmt.Errorf("IDEMPOTENCY_TTL must be a positive duration such as 24h or 30m")
+	}
+	return ttl, nil
+}
+
+// IdempotencyLease — на сколько ключ Idempotency-Key резервируется, пока запрос выполняется
+// (IDEMPOTENCY_LEASE, по умолчанию 1m). Должна превышать время обработки самого долгого запроса.
+func IdempotencyLease() (time.Duration, error) {
+	lease, err := time.ParseDuration(getEnv("IDEMPOTENCY_LEASE", "1m"))
+	if err != nil || lease <= 0 {
+		return 0, fmt.Errorf("IDEMPOTENCY_LEASE must be a positive duration such as 1m or 30s")
+	}
+	return lease, nil
+}
+
+// ReadinessTimeout ограничивает время проверок /health/ready (READINESS_TIMEOUT, по умолчанию 2s).
+func ReadinessTimeout() (time.Duration, error) {
+	timeout, err := time.ParseDuration(getEnv("READINESS_TIMEOUT", "2s"))
+	if err != nil || timeout <= 0 {
+		return 0, fmt.Errorf("READINESS_TIMEOUT must be a positive duration such as 2s or 500ms")
+	}
+	return timeout, nil
+}
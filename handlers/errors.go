@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"PR/apperr"
+	"PR/metrics"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// statusOverrides сохраняет статусы, зафиксированные в исходной спецификации API,
+// там где они расходятся с общим правилом по типу ошибки.
+var statusOverrides = map[string]int{
+	"TEAM_EXISTS": http.StatusBadRequest,
+}
+
+func statusFor(err *apperr.Error) int {
+	if status, ok := statusOverrides[err.Code]; ok {
+		return status
+	}
+
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, apperr.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, apperr.ErrAlreadyExists),
+		errors.Is(err, apperr.ErrConflict),
+		errors.Is(err, apperr.ErrNoCandidate):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func respondError(c *gin.Context, err error) {
+	var appErr *apperr.Error
+	if !errors.As(err, &appErr) {
+		log.Printf("%s %s: %v", c.Request.Method, c.FullPath(), err)
+		c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL_ERROR", "internal error"))
+		return
+	}
+
+	if errors.Is(appErr, apperr.ErrNoCandidate) {
+		metrics.NoCandidate(c.FullPath(), appErr.Code)
+	}
+
+	body := errorResponse(appErr.Code, appErr.Message)
+	if len(appErr.Details) > 0 {
+		body["error"].(gin.H)["details"] = appErr.Details
+	}
+	c.JSON(statusFor(appErr), body)
+}
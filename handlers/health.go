@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"PR/migrations"
+	"PR/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthCheck проверяет одну зависимость сервиса. Details попадают в ответ
+// /health/ready вместе со статусом компонента.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) (details map[string]any, err error)
+}
+
+type HealthHandler struct {
+	checks  []HealthCheck
+	timeout time.Duration
+}
+
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{checks: checks, timeout: timeout}
+}
+
+// Live отвечает, пока процесс способен обрабатывать запросы; зависимости не проверяются.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Ready выполняет все проверки параллельно с общим таймаутом и возвращает 503,
+// если хотя бы один компонент недоступен.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.timeout)
+	defer cancel()
+
+	report := models.ReadinessReport{
+		Status:     models.ReadinessReady,
+		Components: make(map[string]models.ComponentHealth, len(h.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			component := runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[check.Name] = component
+			if component.Status != models.HealthUp {
+				report.Status = models.ReadinessNotReady
+			}
+		}()
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	if report.Status != models.ReadinessReady {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+func runCheck(ctx context.Context, check HealthCheck) models.ComponentHealth {
+	start := time.Now()
+	details, err := check.Check(ctx)
+
+	component := models.ComponentHealth{
+		Status:    models.HealthUp,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		Details:   details,
+	}
+	if err != nil {
+		component.Status = models.HealthDown
+		component.Error = err.Error()
+	}
+	return component
+}
+
+func DatabaseCheck(db *sql.DB) HealthCheck {
+	return HealthCheck{
+		Name: "database",
+		Check: func(ctx context.Context) (map[string]any, error) {
+			return nil, db.PingContext(ctx)
+		},
+	}
+}
+
+// MigrationCheck считает сервис неготовым, если версия схемы отличается от последней
+// встроенной миграции, например пока другой экземпляр ещё применяет миграции.
+func MigrationCheck(migrator *migrations.Migrator) HealthCheck {
+	return HealthCheck{
+		Name: "migrations",
+		Check: func(ctx context.Context) (map[string]any, error) {
+			version, err := migrator.AppliedVersion(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			details := map[string]any{"version": version, "expected": migrator.Latest()}
+			if version != migrator.Latest() {
+				return details, fmt.Errorf("database schema is at version %d, expected %d", version, migrator.Latest())
+			}
+			return details, nil
+		},
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"PR/apperr"
+	"PR/models"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	idempotencyHeader = "Idempotency-Key"
+	replayedHeader    = "Idempotent-Replayed"
+	maxIdempotencyKey = 255
+
+	// Сколько раз пробовать занять ключ, если его запись исчезает между вставкой и чтением.
+	maxReserveAttempts = 3
+)
+
+type IdempotencyStore interface {
+	CreateIdempotencyRecord(record models.IdempotencyRecord) error
+	GetIdempotencyRecord(key, route string, now time.Time) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(key, route string, statusCode int, body []byte, expiresAt time.Time) error
+	DeleteIdempotencyRecord(key, route string) error
+}
+
+// Idempotency запоминает первый ответ на POST-запрос с заголовком Idempotency-Key
+// и в течение ttl возвращает его же на повторы с тем же ключом и телом.
+// Ответы 5xx не запоминаются, чтобы запрос можно было повторить. Пока запрос
+// выполняется, ключ занят не дольше lease: если процесс упадёт, не успев снять
+// резерв, повтор станет возможен после окончания аренды, а не через ttl.
+func Idempotency(store IdempotencyStore, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(idempotencyHeader)
+		route := c.FullPath()
+		if key == "" || route == "" || c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKey {
+			c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", "Idempotency-Key is too long"))
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:])
+
+		now := time.Now()
+		existing, err := reserveIdempotencyKey(store, models.IdempotencyRecord{
+			Key:         key,
+			Route:       route,
+			RequestHash: hash,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(lease),
+		})
+		if err != nil {
+			respondError(c, err)
+			c.Abort()
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, hash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		// Выполняется и при панике обработчика (её перехватывает Recovery выше по цепочке):
+		// резерв ключа снимается, а паника идёт дальше, так как recover здесь не вызывается.
+		finished := false
+		defer func() {
+			if finished {
+				return
+			}
+			if err := store.DeleteIdempotencyRecord(key, route); err != nil {
+				log.Printf("idempotency key %q on %s: %v", key, route, err)
+			}
+		}()
+
+		c.Next()
+
+		status := c.Writer.Status()
+		if status >= http.StatusInternalServerError {
+			err = store.DeleteIdempotencyRecord(key, route)
+		} else {
+			err = store.CompleteIdempotencyRecord(key, route, status, recorder.body.Bytes(), time.Now().Add(ttl))
+		}
+		finished = true
+		if err != nil {
+			log.Printf("idempotency key %q on %s: %v", key, route, err)
+		}
+	}
+}
+
+// reserveIdempotencyKey занимает ключ записью record и возвращает nil либо, если ключ
+// уже занят, возвращает существующую запись. Запись может исчезнуть между неудачной
+// вставкой и чтением, когда первый запрос завершился 5xx или паникой; тогда резерв
+// повторяется, а если ключ так и не удалось ни занять, ни прочитать, повтор получает
+// REQUEST_IN_PROGRESS.
+func reserveIdempotencyKey(store IdempotencyStore, record models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	for attempt := 0; attempt < maxReserveAttempts; attempt++ {
+		err := store.CreateIdempotencyRecord(record)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, apperr.ErrAlreadyExists) {
+			return nil, err
+		}
+
+		existing, err := store.GetIdempotencyRecord(record.Key, record.Route, record.CreatedAt)
+		if err == nil {
+			return existing, nil
+		}
+		if !errors.Is(err, apperr.ErrNotFound) {
+			return nil, err
+		}
+	}
+	return nil, apperr.Conflict("REQUEST_IN_PROGRESS", "request with this idempotency key is still in progress")
+}
+
+func replayIdempotent(c *gin.Context, record *models.IdempotencyRecord, hash string) {
+	defer c.Abort()
+
+	switch {
+	case record.RequestHash != hash:
+		respondError(c, apperr.Conflict("IDEMPOTENCY_KEY_REUSED", "idempotency key was already used with a different request body"))
+	case !record.Completed():
+		respondError(c, apperr.Conflict("REQUEST_IN_PROGRESS", "request with this idempotency key is still in progress"))
+	default:
+		c.Header(replayedHeader, "true")
+		c.Data(record.StatusCode, "application/json; charset=utf-8", record.ResponseBody)
+	}
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,23 @@
+package handlers
+
+import (
+	"PR/metrics"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics считает запросы и их длительность по шаблону маршрута (c.FullPath()),
+// а не по фактическому пути, чтобы число серий не зависело от запросов.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start).Seconds())
+	}
+}
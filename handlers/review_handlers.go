@@ -3,11 +3,21 @@ package handlers
 import (
 	"PR/models"
 	"PR/service"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	actorHeader = "X-Actor-Id"
+
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
 type Handler struct {
 	service *service.ReviewService
 }
@@ -17,25 +27,131 @@ func NewHandler(service *service.ReviewService) *Handler {
 }
 
 func (h *Handler) CreateTeam(c *gin.Context) {
-	var team models.Team
-	if err := c.ShouldBindJSON(&team); err != nil {
+	var req struct {
+		models.Team
+		AllowMoveMembers bool `json:"allow_move_members,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
 		return
 	}
 
-	if err := h.service.CreateTeam(team); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse("TEAM_EXISTS", team.TeamName+" already exists"))
+	team := req.Team
+	if err := h.actingService(c).CreateTeam(&team, req.AllowMoveMembers); err != nil {
+		respondError(c, err)
+		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"team": team})
 }
 
+func (h *Handler) UpdateTeamSettings(c *gin.Context) {
+	var req struct {
+		TeamName string `json:"team_name"`
+		models.TeamSettingsUpdate
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	team, err := h.actingService(c).UpdateTeamSettings(req.TeamName, req.TeamSettingsUpdate)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team": team})
+}
+
+func (h *Handler) AddTeamMembers(c *gin.Context) {
+	var req struct {
+		TeamName string        `json:"team_name"`
+		Members  []models.User `json:"members"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	team, err := h.actingService(c).AddTeamMembers(req.TeamName, req.Members)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team": team})
+}
+
+func (h *Handler) RemoveTeamMember(c *gin.Context) {
+	var req struct {
+		TeamName    string `json:"team_name"`
+		UserID      string `json:"user_id"`
+		OpenReviews string `json:"open_reviews,omitempty"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	report, err := h.actingService(c).RemoveTeamMember(req.TeamName, req.UserID, req.OpenReviews)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) RenameTeam(c *gin.Context) {
+	var req struct {
+		TeamName    string `json:"team_name"`
+		NewTeamName string `json:"new_team_name"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	team, err := h.actingService(c).RenameTeam(req.TeamName, req.NewTeamName)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team": team})
+}
+
+func (h *Handler) DeleteTeam(c *gin.Context) {
+	var req struct {
+		TeamName    string `json:"team_name"`
+		OpenReviews string `json:"open_reviews,omitempty"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	report, err := h.actingService(c).DeleteTeam(req.TeamName, req.OpenReviews)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 func (h *Handler) GetTeam(c *gin.Context) {
 	teamName := c.Query("team_name")
 
 	team, err := h.service.GetTeam(teamName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+		respondError(c, err)
 		return
 	}
 
@@ -53,20 +169,46 @@ func (h *Handler) SetUserActive(c *gin.Context) {
 		return
 	}
 
-	user, err := h.service.SetUserActive(req.UserID, req.IsActive)
+	user, err := h.actingService(c).SetUserActive(req.UserID, req.IsActive)
 	if err != nil {
-		c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user not found"))
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"user": user})
 }
 
+func (h *Handler) MoveUserToTeam(c *gin.Context) {
+	var req struct {
+		UserID              string `json:"user_id"`
+		TeamName            string `json:"team_name"`
+		ReassignOpenReviews bool   `json:"reassign_open_reviews,omitempty"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	user, replaced, removed, err := h.actingService(c).MoveUserToTeam(req.UserID, req.TeamName, req.ReassignOpenReviews)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":                        user,
+		"replacements":                replaced,
+		"removed_without_replacement": removed,
+	})
+}
+
 func (h *Handler) CreatePR(c *gin.Context) {
 	var req struct {
 		PullRequestID   string `json:"pull_request_id"`
 		PullRequestName string `json:"pull_request_name"`
 		AuthorID        string `json:"author_id"`
+		IsDraft         bool   `json:"is_draft,omitempty"`
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -74,16 +216,9 @@ func (h *Handler) CreatePR(c *gin.Context) {
 		return
 	}
 
-	pr, err := h.service.CreatePR(req.PullRequestID, req.PullRequestName, req.AuthorID)
+	pr, err := h.actingService(c).CreatePR(req.PullRequestID, req.PullRequestName, req.AuthorID, req.IsDraft)
 	if err != nil {
-		switch err.Error() {
-		case "PR уже существует":
-			c.JSON(http.StatusConflict, errorResponse("PR_EXISTS", "PR id already exists"))
-		case "Автор не найден", "Команда не найдена":
-			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "author/team not found"))
-		default:
-			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL_ERROR", err.Error()))
-		}
+		respondError(c, err)
 		return
 	}
 
@@ -91,6 +226,45 @@ func (h *Handler) CreatePR(c *gin.Context) {
 }
 
 func (h *Handler) MergePR(c *gin.Context) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+		Override      bool   `json:"override,omitempty"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	pr, err := h.actingService(c).MergePR(req.PullRequestID, req.Override)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pr": pr})
+}
+
+func (h *Handler) MarkReady(c *gin.Context) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	pr, err := h.actingService(c).MarkReady(req.PullRequestID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pr": pr})
+}
+
+func (h *Handler) ClosePR(c *gin.Context) {
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
 	}
@@ -100,15 +274,38 @@ func (h *Handler) MergePR(c *gin.Context) {
 		return
 	}
 
-	pr, err := h.service.MergePR(req.PullRequestID)
+	pr, err := h.actingService(c).ClosePR(req.PullRequestID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "PR not found"))
+		respondError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"pr": pr})
 }
 
+func (h *Handler) ReopenPR(c *gin.Context) {
+	var req struct {
+		PullRequestID string `json:"pull_request_id"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	pr, replaced, removed, err := h.actingService(c).ReopenPR(req.PullRequestID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pr":                          pr,
+		"replacements":                replaced,
+		"removed_without_replacement": removed,
+	})
+}
+
 func (h *Handler) ReassignReviewer(c *gin.Context) {
 	var req struct {
 		PullRequestID string `json:"pull_request_id"`
@@ -120,20 +317,9 @@ func (h *Handler) ReassignReviewer(c *gin.Context) {
 		return
 	}
 
-	pr, newUserID, err := h.service.ReassignReviewer(req.PullRequestID, req.OldUserID)
+	pr, newUserID, err := h.actingService(c).ReassignReviewer(req.PullRequestID, req.OldUserID)
 	if err != nil {
-		switch err.Error() {
-		case "Нельзя переназначать ревьюера на замердженном PR":
-			c.JSON(http.StatusConflict, errorResponse("PR_MERGED", "cannot reassign on merged PR"))
-		case "Данный ревьюер и не был назначен на данный PR":
-			c.JSON(http.StatusConflict, errorResponse("NOT_ASSIGNED", "reviewer is not assigned to this PR"))
-		case "Нет доступных кандидатов для замены":
-			c.JSON(http.StatusConflict, errorResponse("NO_CANDIDATE", "no active replacement candidate in team"))
-		case "PR не найден", "Пользователь не найден":
-			c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "PR/user not found"))
-		default:
-			c.JSON(http.StatusInternalServerError, errorResponse("INTERNAL_ERROR", err.Error()))
-		}
+		respondError(c, err)
 		return
 	}
 
@@ -143,11 +329,47 @@ func (h *Handler) ReassignReviewer(c *gin.Context) {
 	})
 }
 
+func (h *Handler) SubmitReview(c *gin.Context) {
+	var req struct {
+		PullRequestID string             `json:"pull_request_id"`
+		ReviewerID    string             `json:"reviewer_id"`
+		Decision      models.ReviewState `json:"decision"`
+		Comment       string             `json:"comment,omitempty"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	pr, err := h.actingService(c).SubmitReview(req.PullRequestID, req.ReviewerID, req.Decision, req.Comment)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pr": pr})
+}
+
+func (h *Handler) GetPRTimeline(c *gin.Context) {
+	prID := c.Query("pull_request_id")
+	timeline, err := h.service.GetPRTimeline(prID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"pull_request_id": prID,
+		"timeline":        timeline,
+	})
+}
+
 func (h *Handler) GetUserReviews(c *gin.Context) {
 	userID := c.Query("user_id")
 	prs, err := h.service.GetUserReviews(userID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user not found"))
+		respondError(c, err)
 		return
 	}
 
@@ -158,10 +380,52 @@ func (h *Handler) GetUserReviews(c *gin.Context) {
 }
 
 func (h *Handler) GetUserStats(c *gin.Context) {
-	userID := c.Query("user_id")
-	stats, err := h.service.GetUserReviewStats(userID)
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+	to, err := timeQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	status := models.PRStatus(c.Query("status"))
+	stats, err := h.service.GetUserReviewStats(c.Query("user_id"), from, to, status)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *Handler) GetTeamStats(c *gin.Context) {
+	stats, err := h.service.GetTeamReviewStats(c.Query("team_name"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+func (h *Handler) GetLatencyStats(c *gin.Context) {
+	from, err := timeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+	to, err := timeQuery(c, "to")
 	if err != nil {
-		c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "user not found"))
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	stats, err := h.service.GetLatencyStats(c.Query("team_name"), from, to)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 
@@ -179,17 +443,91 @@ func (h *Handler) BulkDeactivateUsers(c *gin.Context) {
 		return
 	}
 
-	affected, err := h.service.BulkDeactivateUsers(req.TeamName, req.ExcludeUsers)
+	report, err := h.actingService(c).BulkDeactivateUsers(req.TeamName, req.ExcludeUsers)
 	if err != nil {
-		c.JSON(http.StatusNotFound, errorResponse("NOT_FOUND", "team not found"))
+		respondError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"team_name":         req.TeamName,
-		"deactivated_users": affected,
-	})
+	c.JSON(http.StatusOK, report)
+}
+
+func (h *Handler) GetAuditEvents(c *gin.Context) {
+	filter := models.AuditFilter{
+		EntityType: c.Query("entity_type"),
+		EntityID:   c.Query("entity_id"),
+		ActorID:    c.Query("actor_id"),
+		Limit:      defaultAuditLimit,
+	}
+
+	var err error
+	if filter.From, err = timeQuery(c, "from"); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+	if filter.To, err = timeQuery(c, "to"); err != nil {
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", err.Error()))
+		return
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", "limit must be between 1 and "+strconv.Itoa(maxAuditLimit)))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := c.Query("after_id"); value != "" {
+		afterID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || afterID < 1 {
+			c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", "after_id must be a positive integer"))
+			return
+		}
+		filter.AfterID = afterID
+	}
+
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		c.JSON(http.StatusBadRequest, errorResponse("INVALID_INPUT", "order must be one of asc, desc"))
+		return
+	}
+
+	events, err := h.service.ListAuditEvents(filter)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	response := gin.H{"events": events}
+	if len(events) == filter.Limit {
+		response["next_after_id"] = events[len(events)-1].ID
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// timeQuery разбирает необязательный параметр запроса в формате RFC3339.
+func timeQuery(c *gin.Context, name string) (*time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be an RFC3339 timestamp", name)
+	}
+	return &t, nil
+}
 
+// actingService возвращает сервис, пишущий журнал аудита от имени автора запроса
+// из заголовка X-Actor-Id.
+func (h *Handler) actingService(c *gin.Context) *service.ReviewService {
+	return h.service.WithActor(c.GetHeader(actorHeader))
 }
 
 func errorResponse(code, message string) gin.H {
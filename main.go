@@ -3,42 +3,128 @@ package main
 import (
 	"PR/config"
 	"PR/handlers"
+	"PR/metrics"
+	"PR/migrations"
 	"PR/repository"
 	"PR/service"
 	"log"
+	"os"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	db, err := config.InitDB()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	var repo repository.Store
+	var healthChecks []handlers.HealthCheck
+	if config.StorageDriver() == "memory" {
+		log.Println("Using in-memory storage")
+		repo = repository.NewMemoryRepository()
+	} else {
+		db, err := config.InitDB()
+		if err != nil {
+			log.Fatal("Failed to connect to database:", err)
+		}
+
+		migrator, err := migrations.NewMigrator(db)
+		if err != nil {
+			log.Fatal("Failed to load migrations:", err)
+		}
+		if config.MigrateOnStart() {
+			if err := migrator.Up(); err != nil {
+				log.Fatal("Failed to migrate database:", err)
+			}
+		}
+		if err := migrator.EnsureCurrent(); err != nil {
+			log.Fatal("Database schema is not up to date: ", err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Fatal("Failed to get database handle:", err)
+		}
+		if err := metrics.RegisterDBStats(sqlDB, config.NewDatabaseConfig().DBName); err != nil {
+			log.Fatal("Failed to register database metrics:", err)
+		}
+
+		healthChecks = append(healthChecks, handlers.DatabaseCheck(sqlDB), handlers.MigrationCheck(migrator))
+		repo = repository.NewRepository(db)
+	}
+
+	if err := metrics.RegisterOpenReviews(repo); err != nil {
+		log.Fatal("Failed to register metrics:", err)
+	}
+
+	seed, seeded, err := config.RandomSeed()
 	if err != nil {
-		log.Fatal("Failed to connect to database:", err)
+		log.Fatal(err)
+	}
+	var random service.Random
+	if seeded {
+		log.Printf("Using deterministic reviewer selection with seed %d", seed)
+		random = service.NewRandom(seed)
 	}
 
-	repo := repository.NewRepository(db)
-	reviewService := service.NewReviewService(repo)
+	reviewService := service.NewReviewService(repo, random)
 	handler := handlers.NewHandler(reviewService)
 
+	idempotencyTTL, err := config.IdempotencyTTL()
+	if err != nil {
+		log.Fatal(err)
+	}
+	idempotencyLease, err := config.IdempotencyLease()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	readinessTimeout, err := config.ReadinessTimeout()
+	if err != nil {
+		log.Fatal(err)
+	}
+	health := handlers.NewHealthHandler(readinessTimeout, healthChecks...)
+
 	r := gin.Default()
+	r.Use(handlers.Metrics())
+	r.Use(handlers.Idempotency(repo, idempotencyTTL, idempotencyLease))
 
 	r.POST("/team/add", handler.CreateTeam)
 	r.GET("/team/get", handler.GetTeam)
+	r.POST("/team/settings", handler.UpdateTeamSettings)
+	r.POST("/team/addMembers", handler.AddTeamMembers)
+	r.POST("/team/removeMember", handler.RemoveTeamMember)
+	r.POST("/team/rename", handler.RenameTeam)
+	r.POST("/team/delete", handler.DeleteTeam)
 
 	r.POST("/users/setIsActive", handler.SetUserActive)
+	r.POST("/users/moveTeam", handler.MoveUserToTeam)
 
 	r.POST("/pullRequest/create", handler.CreatePR)
 	r.POST("/pullRequest/merge", handler.MergePR)
+	r.POST("/pullRequest/close", handler.ClosePR)
+	r.POST("/pullRequest/reopen", handler.ReopenPR)
+	r.POST("/pullRequest/ready", handler.MarkReady)
 	r.POST("/pullRequest/reassign", handler.ReassignReviewer)
+	r.POST("/pullRequest/review", handler.SubmitReview)
+	r.GET("/pullRequest/timeline", handler.GetPRTimeline)
 
 	r.GET("/users/getReview", handler.GetUserReviews)
 
 	r.GET("/stats/user", handler.GetUserStats)
+	r.GET("/stats/team", handler.GetTeamStats)
+	r.GET("/stats/latency", handler.GetLatencyStats)
 	r.POST("/users/bulkDeactivate", handler.BulkDeactivateUsers)
 
-	r.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
-	})
+	r.GET("/audit", handler.GetAuditEvents)
+
+	r.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	r.GET("/health", health.Live)
+	r.GET("/health/live", health.Live)
+	r.GET("/health/ready", health.Ready)
 
 	log.Println("Server starting on :8080")
 	if err := r.Run(":8080"); err != nil {
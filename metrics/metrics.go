@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pr_review"
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and response status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	prsCreated = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_created_total",
+		Help:      "Pull requests created.",
+	})
+
+	prsMerged = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pull_requests_merged_total",
+		Help:      "Pull requests merged.",
+	})
+
+	reassignments = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reviewer_reassignments_total",
+		Help:      "Reviewers replaced on pull requests, by reason.",
+	}, []string{"reason"})
+
+	noCandidate = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "no_candidate_failures_total",
+		Help:      "Requests rejected because no reviewer candidate was available, by route and error code.",
+	}, []string{"route", "code"})
+)
+
+func init() {
+	prometheus.MustRegister(httpRequests, httpDuration, prsCreated, prsMerged, reassignments, noCandidate)
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+func ObserveRequest(method, route string, status int, seconds float64) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(seconds)
+}
+
+func PRCreated() {
+	prsCreated.Inc()
+}
+
+func PRMerged() {
+	prsMerged.Inc()
+}
+
+// ReviewersReassigned учитывает count замен ревьюеров; reason — та же причина, что в журнале аудита.
+func ReviewersReassigned(reason string, count int) {
+	if count > 0 {
+		reassignments.WithLabelValues(reason).Add(float64(count))
+	}
+}
+
+func NoCandidate(route, code string) {
+	noCandidate.WithLabelValues(route, code).Inc()
+}
+
+// RegisterDBStats публикует статистику пула соединений sqlDB (go_sql_*).
+func RegisterDBStats(sqlDB *sql.DB, dbName string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(sqlDB, dbName))
+}
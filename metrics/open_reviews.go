@@ -0,0 +1,39 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type OpenReviewsCounter interface {
+	CountOpenReviewsByTeam() (map[string]int, error)
+}
+
+var openReviewsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "open_reviews"),
+	"Active reviewer assignments on open pull requests, by reviewer team.",
+	[]string{"team"}, nil,
+)
+
+// openReviewsCollector считает открытые ревью при каждом опросе, а не хранит счётчик,
+// поэтому значение не расходится с базой после перезапуска или изменений в обход сервиса.
+type openReviewsCollector struct {
+	store OpenReviewsCounter
+}
+
+func RegisterOpenReviews(store OpenReviewsCounter) error {
+	return prometheus.Register(&openReviewsCollector{store: store})
+}
+
+func (c *openReviewsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- openReviewsDesc
+}
+
+func (c *openReviewsCollector) Collect(ch chan<- prometheus.Metric) {
+	counts, err := c.store.CountOpenReviewsByTeam()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(openReviewsDesc, err)
+		return
+	}
+
+	for team, open := range counts {
+		ch <- prometheus.MustNewConstMetric(openReviewsDesc, prometheus.GaugeValue, float64(open), team)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"PR/config"
+	"PR/migrations"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down | status | to <version>"
+
+// runMigrate обрабатывает подкоманду `migrate`.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	db, err := config.InitDB()
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up()
+	case "down":
+		err = migrator.Down()
+	case "to":
+		if len(args) != 2 {
+			log.Fatal(migrateUsage)
+		}
+		version, convErr := strconv.Atoi(args[1])
+		if convErr != nil {
+			log.Fatal("Invalid version: ", args[1])
+		}
+		err = migrator.To(version)
+	case "status":
+		err = printMigrationStatus(migrator)
+	default:
+		log.Fatal(migrateUsage)
+	}
+	if err != nil {
+		log.Fatal("Migration failed: ", err)
+	}
+
+	if version, err := migrator.Version(); err == nil {
+		log.Printf("Database schema version: %d (latest %d)", version, migrator.Latest())
+	}
+}
+
+func printMigrationStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+
+	for _, status := range statuses {
+		applied := "pending"
+		if status.AppliedAt != nil {
+			applied = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("%04d  %-30s %s\n", status.Version, status.Name, applied)
+	}
+	return nil
+}
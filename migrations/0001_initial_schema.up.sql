@@ -0,0 +1,113 @@
+-- Начальная схема. Написана идемпотентно, чтобы её можно было применить поверх базы,
+-- созданной прежним AutoMigrate: недостающие таблицы, колонки и ограничения добавляются,
+-- существующие данные не трогаются.
+
+CREATE TABLE IF NOT EXISTS teams (
+    team_name text PRIMARY KEY
+);
+
+ALTER TABLE teams
+    ADD COLUMN IF NOT EXISTS assignment_strategy varchar(32) NOT NULL DEFAULT 'least_loaded',
+    ADD COLUMN IF NOT EXISTS min_reviewers integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_reviewers integer NOT NULL DEFAULT 2,
+    ADD COLUMN IF NOT EXISTS required_approvals integer NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS block_on_changes_requested boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS forbid_self_approval boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id   text PRIMARY KEY,
+    username  text,
+    team_name text NOT NULL,
+    is_active boolean
+);
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS review_weight integer NOT NULL DEFAULT 1;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_teams_members') THEN
+        ALTER TABLE users ADD CONSTRAINT fk_teams_members
+            FOREIGN KEY (team_name) REFERENCES teams (team_name);
+    END IF;
+END $$;
+
+CREATE TABLE IF NOT EXISTS pull_requests (
+    pull_request_id   text PRIMARY KEY,
+    pull_request_name text,
+    author_id         text NOT NULL,
+    status            varchar(20) DEFAULT 'OPEN',
+    created_at        timestamptz,
+    merged_at         timestamptz
+);
+
+ALTER TABLE pull_requests
+    ADD COLUMN IF NOT EXISTS is_draft boolean NOT NULL DEFAULT false,
+    ADD COLUMN IF NOT EXISTS closed_at timestamptz,
+    ADD COLUMN IF NOT EXISTS merge_override boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS pr_reviewers (
+    pull_request_id text NOT NULL,
+    user_id         text NOT NULL,
+    assigned_at     timestamptz NOT NULL,
+    state           varchar(32) NOT NULL DEFAULT 'PENDING',
+    comment         text,
+    decided_at      timestamptz,
+    replaced_by     text,
+    removed_at      timestamptz,
+    PRIMARY KEY (pull_request_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_pr_reviewers_user_id ON pr_reviewers (user_id);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_pull_requests_reviewers') THEN
+        ALTER TABLE pr_reviewers ADD CONSTRAINT fk_pull_requests_reviewers
+            FOREIGN KEY (pull_request_id) REFERENCES pull_requests (pull_request_id) ON DELETE CASCADE;
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_pr_reviewers_reviewer') THEN
+        ALTER TABLE pr_reviewers ADD CONSTRAINT fk_pr_reviewers_reviewer
+            FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
+    END IF;
+END $$;
+
+-- Базы до появления pr_reviewers хранили ревьюеров в pull_requests.assigned_reviewers (text[]),
+-- а решения — в pr_reviews. Переносим их и удаляем старые колонку и таблицу.
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_schema = current_schema()
+          AND table_name = 'pull_requests'
+          AND column_name = 'assigned_reviewers'
+    ) THEN
+        RETURN;
+    END IF;
+
+    IF to_regclass('pr_reviews') IS NOT NULL THEN
+        INSERT INTO pr_reviewers (pull_request_id, user_id, assigned_at, state, comment, decided_at)
+        SELECT a.pull_request_id, a.user_id, COALESCE(a.created_at, now()),
+               COALESCE(d.state, 'PENDING'), d.comment, d.submitted_at
+        FROM (
+            SELECT pull_request_id, unnest(assigned_reviewers) AS user_id, created_at
+            FROM pull_requests
+        ) a
+        JOIN users u ON u.user_id = a.user_id
+        LEFT JOIN pr_reviews d ON d.pull_request_id = a.pull_request_id AND d.reviewer_id = a.user_id
+        ON CONFLICT DO NOTHING;
+
+        DROP TABLE pr_reviews;
+    ELSE
+        INSERT INTO pr_reviewers (pull_request_id, user_id, assigned_at)
+        SELECT a.pull_request_id, a.user_id, COALESCE(a.created_at, now())
+        FROM (
+            SELECT pull_request_id, unnest(assigned_reviewers) AS user_id, created_at
+            FROM pull_requests
+        ) a
+        JOIN users u ON u.user_id = a.user_id
+        ON CONFLICT DO NOTHING;
+    END IF;
+
+    ALTER TABLE pull_requests DROP COLUMN assigned_reviewers;
+END $$;
@@ -0,0 +1,24 @@
+CREATE TABLE audit_events (
+    id          bigserial PRIMARY KEY,
+    action      varchar(64) NOT NULL,
+    entity_type varchar(32) NOT NULL,
+    entity_id   text NOT NULL,
+    actor_id    text NOT NULL,
+    details     jsonb,
+    created_at  timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_audit_events_entity ON audit_events (entity_type, entity_id);
+CREATE INDEX idx_audit_events_actor ON audit_events (actor_id);
+CREATE INDEX idx_audit_events_created_at ON audit_events (created_at);
+
+-- Журнал только дополняется: изменение и удаление записей запрещены.
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_append_only
+    BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
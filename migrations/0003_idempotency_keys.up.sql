@@ -0,0 +1,12 @@
+CREATE TABLE idempotency_keys (
+    idempotency_key text NOT NULL,
+    route           text NOT NULL,
+    request_hash    text NOT NULL,
+    status_code     integer NOT NULL DEFAULT 0,
+    response_body   bytea,
+    created_at      timestamptz NOT NULL,
+    expires_at      timestamptz NOT NULL,
+    PRIMARY KEY (idempotency_key, route)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_pull_requests_merged_at;
+DROP INDEX IF EXISTS idx_pr_reviewers_first_decided_at;
+ALTER TABLE pr_reviewers DROP COLUMN IF EXISTS first_decided_at;
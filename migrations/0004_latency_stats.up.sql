@@ -0,0 +1,7 @@
+-- decided_at перезаписывается при каждом новом решении, поэтому время первого
+-- решения хранится отдельно. Для уже принятых решений лучшее приближение — decided_at.
+ALTER TABLE pr_reviewers ADD COLUMN first_decided_at timestamptz;
+UPDATE pr_reviewers SET first_decided_at = decided_at WHERE decided_at IS NOT NULL;
+
+CREATE INDEX idx_pr_reviewers_first_decided_at ON pr_reviewers (first_decided_at);
+CREATE INDEX idx_pull_requests_merged_at ON pull_requests (merged_at);
@@ -0,0 +1 @@
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS forbid_self_approval boolean NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+-- Автор никогда не назначается ревьюером своего PR и не может оставить по нему решение,
+-- поэтому настройка forbid_self_approval ни на что не влияла.
+ALTER TABLE teams DROP COLUMN IF EXISTS forbid_self_approval;
@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_pr_reviewers_active;
+DROP INDEX IF EXISTS idx_pr_reviewers_pull_request_id;
+
+-- Прежний ключ допускает одну строку на пару (PR, пользователь): оставляем последнее назначение.
+DELETE FROM pr_reviewers r
+USING pr_reviewers newer
+WHERE newer.pull_request_id = r.pull_request_id
+  AND newer.user_id = r.user_id
+  AND newer.id > r.id;
+
+ALTER TABLE pr_reviewers DROP COLUMN id;
+ALTER TABLE pr_reviewers ADD PRIMARY KEY (pull_request_id, user_id);
@@ -0,0 +1,9 @@
+-- Повторное назначение того же ревьюера на PR должно добавлять новую строку, а не
+-- перезаписывать прежнюю вместе с её решением и removed_at, поэтому ключом становится
+-- суррогатный id. Действующее назначение пользователя на PR по-прежнему одно.
+ALTER TABLE pr_reviewers DROP CONSTRAINT pr_reviewers_pkey;
+ALTER TABLE pr_reviewers ADD COLUMN id bigserial PRIMARY KEY;
+
+CREATE INDEX idx_pr_reviewers_pull_request_id ON pr_reviewers (pull_request_id);
+CREATE UNIQUE INDEX idx_pr_reviewers_active ON pr_reviewers (pull_request_id, user_id)
+    WHERE removed_at IS NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE pr_reviewers DROP CONSTRAINT fk_pr_reviewers_reviewer;
+ALTER TABLE pr_reviewers ADD CONSTRAINT fk_pr_reviewers_reviewer
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE CASCADE;
+
+-- Прежняя схема не допускает пользователей без команды: удаляем их вместе с назначениями.
+DELETE FROM users WHERE team_name IS NULL;
+ALTER TABLE users ALTER COLUMN team_name SET NOT NULL;
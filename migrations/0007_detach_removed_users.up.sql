@@ -0,0 +1,8 @@
+-- Участники, удалённые из команды, больше не удаляются из users, а остаются без команды,
+-- чтобы их назначения и авторство PR сохранялись. Каскадное удаление назначений
+-- вместе с пользователем заменяется запретом.
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
+
+ALTER TABLE pr_reviewers DROP CONSTRAINT fk_pr_reviewers_reviewer;
+ALTER TABLE pr_reviewers ADD CONSTRAINT fk_pr_reviewers_reviewer
+    FOREIGN KEY (user_id) REFERENCES users (user_id) ON DELETE RESTRICT;
@@ -0,0 +1,293 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// lockID — ключ advisory-блокировки, чтобы два экземпляра сервиса не мигрировали базу одновременно.
+const lockID = 7240913
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+type schemaMigration struct {
+	Version   int       `gorm:"primaryKey"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Load читает встроенные файлы миграций вида 0001_name.up.sql / 0001_name.down.sql.
+func Load() ([]Migration, error) {
+	entries, err := files.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := files.ReadFile(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d (%s) must have both up and down files", migration.Version, migration.Name)
+		}
+		result = append(result, *migration)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest — версия последней миграции, встроенной в бинарник.
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version — последняя применённая к базе версия, 0 для пустой базы.
+func (m *Migrator) Version() (int, error) {
+	if err := m.ensureTable(); err != nil {
+		return 0, err
+	}
+	return currentVersion(m.db)
+}
+
+// AppliedVersion — как Version, но без создания schema_migrations: для проверок
+// готовности, которые не должны менять схему. Отсутствие таблицы — ошибка.
+func (m *Migrator) AppliedVersion(ctx context.Context) (int, error) {
+	return currentVersion(m.db.WithContext(ctx))
+}
+
+func (m *Migrator) Status() ([]Status, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	var applied []schemaMigration
+	if err := m.db.Order("version").Find(&applied).Error; err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]schemaMigration, len(applied))
+	for _, row := range applied {
+		byVersion[row.Version] = row
+	}
+
+	result := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if row, ok := byVersion[migration.Version]; ok {
+			status.Applied = true
+			status.AppliedAt = &row.AppliedAt
+			delete(byVersion, migration.Version)
+		}
+		result = append(result, status)
+	}
+
+	// Версии, о которых бинарник не знает (база мигрирована более новой сборкой).
+	for _, row := range applied {
+		if _, ok := byVersion[row.Version]; ok {
+			result = append(result, Status{Version: row.Version, Name: row.Name, Applied: true, AppliedAt: &row.AppliedAt})
+		}
+	}
+	return result, nil
+}
+
+func (m *Migrator) Up() error {
+	return m.To(m.Latest())
+}
+
+// Down откатывает одну последнюю применённую миграцию.
+func (m *Migrator) Down() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+
+	target := 0
+	for _, migration := range m.migrations {
+		if migration.Version < version {
+			target = migration.Version
+		}
+	}
+	return m.To(target)
+}
+
+// To применяет или откатывает миграции по одной, пока версия базы не станет равна target.
+// Каждая миграция выполняется в своей транзакции вместе с записью в schema_migrations.
+func (m *Migrator) To(target int) error {
+	if target != 0 && m.find(target) == nil {
+		return fmt.Errorf("unknown migration version %d", target)
+	}
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+
+	for {
+		done, err := m.step(target)
+		if err != nil || done {
+			return err
+		}
+	}
+}
+
+// EnsureCurrent возвращает ошибку, если схема базы не совпадает с последней миграцией.
+func (m *Migrator) EnsureCurrent() error {
+	version, err := m.Version()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case version < m.Latest():
+		return fmt.Errorf("database schema is at version %d, expected %d: run `migrate up` or set MIGRATE_ON_START=true", version, m.Latest())
+	case version > m.Latest():
+		return fmt.Errorf("database schema is at version %d, newer than this build supports (%d)", version, m.Latest())
+	}
+	return nil
+}
+
+func (m *Migrator) step(target int) (bool, error) {
+	done := false
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockID).Error; err != nil {
+			return err
+		}
+
+		version, err := currentVersion(tx)
+		if err != nil {
+			return err
+		}
+		if version == target {
+			done = true
+			return nil
+		}
+
+		if version < target {
+			next := m.next(version)
+			log.Printf("Применяем миграцию %04d_%s", next.Version, next.Name)
+			if err := tx.Exec(next.Up).Error; err != nil {
+				return fmt.Errorf("migration %04d_%s up: %w", next.Version, next.Name, err)
+			}
+			return tx.Create(&schemaMigration{Version: next.Version, Name: next.Name, AppliedAt: time.Now()}).Error
+		}
+
+		current := m.find(version)
+		if current == nil {
+			return fmt.Errorf("cannot roll back unknown migration version %d", version)
+		}
+		log.Printf("Откатываем миграцию %04d_%s", current.Version, current.Name)
+		if err := tx.Exec(current.Down).Error; err != nil {
+			return fmt.Errorf("migration %04d_%s down: %w", current.Version, current.Name, err)
+		}
+		return tx.Delete(&schemaMigration{}, current.Version).Error
+	})
+	return done, err
+}
+
+func (m *Migrator) ensureTable() error {
+	return m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    integer PRIMARY KEY,
+		name       text NOT NULL,
+		applied_at timestamptz NOT NULL
+	)`).Error
+}
+
+func (m *Migrator) find(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func (m *Migrator) next(version int) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version > version {
+			return &m.migrations[i]
+		}
+	}
+	return nil
+}
+
+func currentVersion(db *gorm.DB) (int, error) {
+	var version int
+	if err := db.Model(&schemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
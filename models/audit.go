@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	AuditEntityTeam        = "team"
+	AuditEntityUser        = "user"
+	AuditEntityPullRequest = "pull_request"
+
+	AuditTeamCreated         = "team.created"
+	AuditTeamSettingsUpdated = "team.settings_updated"
+	AuditTeamMemberAdded     = "team.member_added"
+	AuditTeamMemberRemoved   = "team.member_removed"
+	AuditTeamRenamed         = "team.renamed"
+	AuditTeamDeleted         = "team.deleted"
+	AuditUserActivated       = "user.activated"
+	AuditUserDeactivated     = "user.deactivated"
+	AuditUserMoved           = "user.moved"
+	AuditPRCreated           = "pr.created"
+	AuditPRReady             = "pr.ready"
+	AuditPRMerged            = "pr.merged"
+	AuditPRClosed            = "pr.closed"
+	AuditPRReopened          = "pr.reopened"
+	AuditReviewerAssigned    = "reviewer.assigned"
+	AuditReviewerReplaced    = "reviewer.replaced"
+	AuditReviewerRemoved     = "reviewer.removed"
+	AuditReviewSubmitted     = "review.submitted"
+
+	// SystemActor подставляется, когда вызывающий не представился.
+	SystemActor = "system"
+)
+
+// AuditEvent — запись журнала аудита. Журнал только дополняется.
+type AuditEvent struct {
+	ID         int64           `gorm:"primaryKey;autoIncrement" json:"id"`
+	Action     string          `gorm:"column:action;not null" json:"action"`
+	EntityType string          `gorm:"column:entity_type;not null" json:"entity_type"`
+	EntityID   string          `gorm:"column:entity_id;not null" json:"entity_id"`
+	ActorID    string          `gorm:"column:actor_id;not null" json:"actor_id"`
+	Details    json.RawMessage `gorm:"column:details;type:jsonb" json:"details,omitempty"`
+	CreatedAt  time.Time       `gorm:"column:created_at;not null" json:"created_at"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+// AuditFilter задаёт выборку журнала. AfterID — курсор: id последнего события
+// предыдущей страницы; следующая страница продолжается в том же порядке.
+type AuditFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    string
+	From       *time.Time
+	To         *time.Time
+	AfterID    int64
+	Descending bool
+	Limit      int
+}
@@ -0,0 +1,22 @@
+package models
+
+type ReviewerReplacement struct {
+	PullRequestID string `json:"pull_request_id"`
+	OldUserID     string `json:"old_user_id"`
+	NewUserID     string `json:"new_user_id"`
+}
+
+type RemovedReviewer struct {
+	PullRequestID string `json:"pull_request_id"`
+	UserID        string `json:"user_id"`
+}
+
+type BulkDeactivationReport struct {
+	TeamName                  string                `json:"team_name"`
+	DeactivatedUsers          int64                 `json:"deactivated_users"`
+	DeactivatedUserIDs        []string              `json:"deactivated_user_ids"`
+	ChangedPullRequests       []string              `json:"changed_pull_requests"`
+	Replacements              []ReviewerReplacement `json:"replacements"`
+	RemovedWithoutReplacement []RemovedReviewer     `json:"removed_without_replacement"`
+	DurationMs                int64                 `json:"duration_ms"`
+}
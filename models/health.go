@@ -0,0 +1,21 @@
+package models
+
+const (
+	HealthUp   = "up"
+	HealthDown = "down"
+
+	ReadinessReady    = "ready"
+	ReadinessNotReady = "not_ready"
+)
+
+type ComponentHealth struct {
+	Status    string         `json:"status"`
+	LatencyMs float64        `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+type ReadinessReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// IdempotencyRecord хранит первый ответ на запрос с заголовком Idempotency-Key.
+// Пока запрос выполняется, StatusCode равен нулю, а ExpiresAt — конец короткой аренды
+// ключа; после сохранения ответа ExpiresAt продлевается на время хранения ответа.
+type IdempotencyRecord struct {
+	Key          string    `gorm:"primaryKey;column:idempotency_key"`
+	Route        string    `gorm:"primaryKey;column:route"`
+	RequestHash  string    `gorm:"column:request_hash;not null"`
+	StatusCode   int       `gorm:"column:status_code;not null;default:0"`
+	ResponseBody []byte    `gorm:"column:response_body"`
+	CreatedAt    time.Time `gorm:"column:created_at;not null"`
+	ExpiresAt    time.Time `gorm:"column:expires_at;not null"`
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
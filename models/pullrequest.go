@@ -1,9 +1,8 @@
 package models
 
 import (
+	"errors"
 	"time"
-
-	"github.com/jackc/pgtype"
 )
 
 type PRStatus string
@@ -11,26 +10,128 @@ type PRStatus string
 const (
 	StatusOpen     PRStatus = "OPEN"
 	StatusMerged   PRStatus = "MERGED"
+	StatusClosed   PRStatus = "CLOSED"
 	StatusNotFound PRStatus = "NOT_FOUND"
 )
 
+var prTransitions = map[PRStatus][]PRStatus{
+	StatusOpen:   {StatusMerged, StatusClosed},
+	StatusClosed: {StatusOpen},
+}
+
+func (s PRStatus) CanTransitionTo(next PRStatus) bool {
+	for _, allowed := range prTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 type PullRequest struct {
-	PullRequestID     string           `gorm:"primaryKey;column:pull_request_id" json:"pull_request_id"`
-	PullRequestName   string           `gorm:"column:pull_request_name" json:"pull_request_name"`
-	AuthorID          string           `gorm:"column:author_id;not null" json:"author_id"`
-	Status            PRStatus         `gorm:"type:varchar(20);default:'OPEN'" json:"status"`
-	AssignedReviewers pgtype.TextArray `gorm:"type:text[]" json:"assigned_reviewers"`
-	CreatedAt         time.Time        `gorm:"autoCreateTime" json:"createdAt"`
-	MergedAt          *time.Time       `json:"mergedAt,omitempty"`
+	PullRequestID     string       `gorm:"primaryKey;column:pull_request_id" json:"pull_request_id"`
+	PullRequestName   string       `gorm:"column:pull_request_name" json:"pull_request_name"`
+	AuthorID          string       `gorm:"column:author_id;not null" json:"author_id"`
+	Status            PRStatus     `gorm:"type:varchar(20);default:'OPEN'" json:"status"`
+	IsDraft           bool         `gorm:"column:is_draft;not null;default:false" json:"is_draft"`
+	AssignedReviewers []string     `gorm:"-" json:"assigned_reviewers"`
+	CreatedAt         time.Time    `gorm:"autoCreateTime" json:"createdAt"`
+	MergedAt          *time.Time   `json:"mergedAt,omitempty"`
+	ClosedAt          *time.Time   `json:"closedAt,omitempty"`
+	MergeOverride     bool         `gorm:"column:merge_override;not null;default:false" json:"merge_override,omitempty"`
+	Reviews           []PRReviewer `gorm:"-" json:"reviews"`
+
+	Reviewers []PRReviewer `gorm:"foreignKey:PullRequestID;references:PullRequestID;constraint:OnDelete:CASCADE" json:"-"`
 }
 
 func (PullRequest) TableName() string {
 	return "pull_requests"
 }
 
+var ErrReviewerNotAssigned = errors.New("reviewer is not assigned")
+
+// SyncReviewers пересчитывает AssignedReviewers и Reviews по строкам Reviewers.
+// Вызывается после загрузки PR и после каждого изменения назначений.
+func (pr *PullRequest) SyncReviewers() {
+	pr.AssignedReviewers = []string{}
+	pr.Reviews = []PRReviewer{}
+	for _, reviewer := range pr.Reviewers {
+		if reviewer.IsActive() {
+			pr.AssignedReviewers = append(pr.AssignedReviewers, reviewer.UserID)
+			pr.Reviews = append(pr.Reviews, reviewer)
+		}
+	}
+}
+
+func (pr *PullRequest) HasReviewer(userID string) bool {
+	_, ok := pr.activeReviewer(userID)
+	return ok
+}
+
+// AssignReviewer всегда добавляет новое назначение, даже если пользователь уже
+// назначался на этот PR раньше: история прежних назначений не перезаписывается.
+func (pr *PullRequest) AssignReviewer(userID string, at time.Time) {
+	pr.Reviewers = append(pr.Reviewers, PRReviewer{
+		PullRequestID: pr.PullRequestID,
+		UserID:        userID,
+		AssignedAt:    at,
+		State:         ReviewPending,
+	})
+	pr.SyncReviewers()
+}
+
+func (pr *PullRequest) RemoveReviewer(userID string, at time.Time) error {
+	i, ok := pr.activeReviewer(userID)
+	if !ok {
+		return ErrReviewerNotAssigned
+	}
+
+	pr.Reviewers[i].RemovedAt = &at
+	pr.SyncReviewers()
+	return nil
+}
+
+func (pr *PullRequest) ReplaceReviewer(oldUserID, newUserID string, at time.Time) error {
+	i, ok := pr.activeReviewer(oldUserID)
+	if !ok {
+		return ErrReviewerNotAssigned
+	}
+
+	pr.Reviewers[i].RemovedAt = &at
+	pr.Reviewers[i].ReplacedBy = &newUserID
+	pr.AssignReviewer(newUserID, at)
+	return nil
+}
+
+func (pr *PullRequest) SetReview(userID string, state ReviewState, comment string, at time.Time) error {
+	i, ok := pr.activeReviewer(userID)
+	if !ok {
+		return ErrReviewerNotAssigned
+	}
+
+	pr.Reviewers[i].State = state
+	pr.Reviewers[i].Comment = comment
+	pr.Reviewers[i].DecidedAt = &at
+	if pr.Reviewers[i].FirstDecidedAt == nil {
+		pr.Reviewers[i].FirstDecidedAt = &at
+	}
+	pr.SyncReviewers()
+	return nil
+}
+
+func (pr *PullRequest) activeReviewer(userID string) (int, bool) {
+	for i, reviewer := range pr.Reviewers {
+		if reviewer.UserID == userID && reviewer.IsActive() {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
 type PullRequestShort struct {
-	PullRequestID   string   `json:"pull_request_id"`
-	PullRequestName string   `json:"pull_request_name"`
-	AuthorID        string   `json:"author_id"`
-	Status          PRStatus `json:"status"`
+	PullRequestID   string      `json:"pull_request_id"`
+	PullRequestName string      `json:"pull_request_name"`
+	AuthorID        string      `json:"author_id"`
+	Status          PRStatus    `json:"status"`
+	ReviewState     ReviewState `json:"review_state"`
 }
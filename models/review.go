@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+type ReviewState string
+
+const (
+	ReviewPending          ReviewState = "PENDING"
+	ReviewApproved         ReviewState = "APPROVED"
+	ReviewChangesRequested ReviewState = "CHANGES_REQUESTED"
+	ReviewCommented        ReviewState = "COMMENTED"
+)
+
+func (s ReviewState) IsDecision() bool {
+	return s == ReviewApproved || s == ReviewChangesRequested || s == ReviewCommented
+}
+
+// PRReviewer — назначение ревьюера на PR. Строки не удаляются: снятый ревьюер
+// получает RemovedAt, а если его заменили — ещё и ReplacedBy. Повторное назначение
+// того же пользователя добавляет новую строку, прежняя с её решением сохраняется.
+type PRReviewer struct {
+	ID             int64       `gorm:"primaryKey;autoIncrement" json:"-"`
+	PullRequestID  string      `gorm:"column:pull_request_id;not null;index" json:"-"`
+	UserID         string      `gorm:"column:user_id;not null;index" json:"reviewer_id"`
+	AssignedAt     time.Time   `gorm:"column:assigned_at;not null" json:"assignedAt"`
+	State          ReviewState `gorm:"column:state;type:varchar(32);not null;default:'PENDING'" json:"state"`
+	Comment        string      `gorm:"column:comment" json:"comment,omitempty"`
+	DecidedAt      *time.Time  `gorm:"column:decided_at" json:"submittedAt,omitempty"`
+	FirstDecidedAt *time.Time  `gorm:"column:first_decided_at" json:"-"`
+	ReplacedBy     *string     `gorm:"column:replaced_by" json:"-"`
+	RemovedAt      *time.Time  `gorm:"column:removed_at" json:"-"`
+
+	Reviewer *User `gorm:"foreignKey:UserID;references:UserId;constraint:OnDelete:RESTRICT" json:"-"`
+}
+
+func (PRReviewer) TableName() string {
+	return "pr_reviewers"
+}
+
+func (r PRReviewer) IsActive() bool {
+	return r.RemovedAt == nil
+}
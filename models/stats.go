@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+// UserReviewStats — статистика назначений пользователя ревьюером. Назначения,
+// с которых его сняли или заменили, считаются в ReassignedAway, остальные —
+// по статусу PR.
+type UserReviewStats struct {
+	UserID           string     `json:"user_id"`
+	From             *time.Time `json:"from,omitempty"`
+	To               *time.Time `json:"to,omitempty"`
+	Status           PRStatus   `json:"status,omitempty"`
+	TotalReviews     int        `json:"total_reviews"`
+	OpenReviews      int        `json:"open_reviews"`
+	MergedReviews    int        `json:"merged_reviews"`
+	ClosedReviews    int        `json:"closed_reviews"`
+	CompletedReviews int        `json:"completed_reviews"`
+	ReassignedAway   int        `json:"reassigned_away"`
+}
+
+type MemberReviewStats struct {
+	UserID           string `json:"user_id"`
+	Username         string `json:"username"`
+	IsActive         bool   `json:"is_active"`
+	OpenReviews      int    `json:"open_reviews"`
+	CompletedReviews int    `json:"completed_reviews"`
+	TotalReviews     int    `json:"total_reviews"`
+}
+
+// ReviewCounts — действующие назначения ревьюера на открытые и на слитые или закрытые PR.
+type ReviewCounts struct {
+	Open      int
+	Completed int
+}
+
+// ReviewFairness показывает, насколько равномерно текущая нагрузка распределена между
+// активными участниками команды (по open_reviews).
+type ReviewFairness struct {
+	Gini        float64  `json:"gini"`
+	MaxReviews  int      `json:"max_reviews"`
+	MinReviews  int      `json:"min_reviews"`
+	MaxMinRatio *float64 `json:"max_min_ratio"`
+}
+
+type TeamReviewStats struct {
+	TeamName          string              `json:"team_name"`
+	Members           []MemberReviewStats `json:"members"`
+	OpenPullRequests  int                 `json:"open_pull_requests"`
+	TotalPullRequests int                 `json:"total_pull_requests"`
+	AvgReviewersPerPR float64             `json:"avg_reviewers_per_pr"`
+	Fairness          ReviewFairness      `json:"fairness"`
+}
+
+// MergeTiming — слитый PR для статистики скорости. TeamName — текущая команда автора,
+// пустая, если автора убрали из команды.
+type MergeTiming struct {
+	PullRequestID string
+	AuthorID      string
+	TeamName      string
+	CreatedAt     time.Time
+	MergedAt      time.Time
+}
+
+// DecisionTiming — первое решение ревьюера по назначению. TeamName — текущая команда ревьюера.
+type DecisionTiming struct {
+	UserID         string
+	TeamName       string
+	AssignedAt     time.Time
+	FirstDecidedAt time.Time
+}
+
+// DurationStats — распределение длительностей в часах.
+type DurationStats struct {
+	Count       int     `json:"count"`
+	MedianHours float64 `json:"median_hours"`
+	P90Hours    float64 `json:"p90_hours"`
+}
+
+type TeamDurationStats struct {
+	TeamName string `json:"team_name"`
+	DurationStats
+}
+
+type AuthorDurationStats struct {
+	AuthorID string `json:"author_id"`
+	DurationStats
+}
+
+type ReviewerDurationStats struct {
+	ReviewerID string `json:"reviewer_id"`
+	DurationStats
+}
+
+type WeeklyLatencyStats struct {
+	WeekStart   string        `json:"week_start"`
+	TimeToMerge DurationStats `json:"time_to_merge"`
+	FirstReview DurationStats `json:"first_review"`
+}
+
+type LatencyStats struct {
+	From                  *time.Time              `json:"from,omitempty"`
+	To                    *time.Time              `json:"to,omitempty"`
+	TeamName              string                  `json:"team_name,omitempty"`
+	TimeToMerge           DurationStats           `json:"time_to_merge"`
+	TimeToMergeByTeam     []TeamDurationStats     `json:"time_to_merge_by_team"`
+	TimeToMergeByAuthor   []AuthorDurationStats   `json:"time_to_merge_by_author"`
+	FirstReviewByReviewer []ReviewerDurationStats `json:"first_review_by_reviewer"`
+	Weekly                []WeeklyLatencyStats    `json:"weekly"`
+}
@@ -2,11 +2,51 @@ package models
 
 import _ "gorm.io/gorm"
 
+const (
+	StrategyRandom      = "random"
+	StrategyRoundRobin  = "round_robin"
+	StrategyLeastLoaded = "least_loaded"
+	StrategyWeighted    = "weighted"
+
+	DefaultAssignmentStrategy = StrategyLeastLoaded
+	DefaultMaxReviewers       = 2
+
+	// Что делать с открытыми ревью уходящих участников при удалении из команды.
+	OpenReviewsBlock    = "block"
+	OpenReviewsReassign = "reassign"
+)
+
 type Team struct {
-	TeamName string `gorm:"primaryKey" json:"team_name"`
-	Members  []User `gorm:"foreignKey:TeamName;references:TeamName" json:"members"`
+	TeamName           string `gorm:"primaryKey" json:"team_name"`
+	AssignmentStrategy string `gorm:"column:assignment_strategy;type:varchar(32);not null;default:'least_loaded'" json:"assignment_strategy"`
+	MinReviewers       int    `gorm:"column:min_reviewers;not null;default:0" json:"min_reviewers"`
+	MaxReviewers       int    `gorm:"column:max_reviewers;not null;default:2" json:"max_reviewers"`
+
+	RequiredApprovals       int  `gorm:"column:required_approvals;not null;default:0" json:"required_approvals"`
+	BlockOnChangesRequested bool `gorm:"column:block_on_changes_requested;not null;default:false" json:"block_on_changes_requested"`
+
+	Members []User `gorm:"foreignKey:TeamName;references:TeamName" json:"members"`
 }
 
 func (Team) TableName() string {
 	return "teams"
 }
+
+type TeamSettingsUpdate struct {
+	AssignmentStrategy *string `json:"assignment_strategy,omitempty"`
+	MinReviewers       *int    `json:"min_reviewers,omitempty"`
+	MaxReviewers       *int    `json:"max_reviewers,omitempty"`
+
+	RequiredApprovals       *int  `json:"required_approvals,omitempty"`
+	BlockOnChangesRequested *bool `json:"block_on_changes_requested,omitempty"`
+}
+
+// TeamChangeReport описывает последствия удаления участников или всей команды
+// для открытых PR, на которые они были назначены ревьюерами.
+type TeamChangeReport struct {
+	TeamName                  string                `json:"team_name"`
+	RemovedUserIDs            []string              `json:"removed_user_ids"`
+	ChangedPullRequests       []string              `json:"changed_pull_requests"`
+	Replacements              []ReviewerReplacement `json:"replacements"`
+	RemovedWithoutReplacement []RemovedReviewer     `json:"removed_without_replacement"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// TimelineEntry — шаг истории PR, собранный из события аудита.
+// Поля, кроме At, Event и ActorID, заполняются в зависимости от типа события.
+type TimelineEntry struct {
+	At      time.Time `json:"at"`
+	Event   string    `json:"event"`
+	ActorID string    `json:"actor_id"`
+
+	AuthorID      string      `json:"author_id,omitempty"`
+	IsDraft       bool        `json:"is_draft,omitempty"`
+	ReviewerID    string      `json:"reviewer_id,omitempty"`
+	OldReviewerID string      `json:"old_reviewer_id,omitempty"`
+	NewReviewerID string      `json:"new_reviewer_id,omitempty"`
+	Strategy      string      `json:"strategy,omitempty"`
+	CandidatePool *int        `json:"candidate_pool,omitempty"`
+	Reason        string      `json:"reason,omitempty"`
+	Decision      ReviewState `json:"decision,omitempty"`
+	Comment       string      `json:"comment,omitempty"`
+	Override      bool        `json:"override,omitempty"`
+	UnmetPolicy   []string    `json:"unmet_policy,omitempty"`
+}
@@ -5,6 +5,9 @@ import _ "gorm.io/gorm"
 type User struct {
 	UserId   string `gorm:"primaryKey;column:user_id" json:"user_id"`
 	UserName string `gorm:"column:username" json:"username"`
-	TeamName string `gorm:"not null" json:"team_name"`
+	// TeamName пуст (NULL в БД) у пользователей, удалённых из команды.
+	TeamName string `json:"team_name"`
 	IsActive bool   `json:"is_active"`
+
+	ReviewWeight int `gorm:"column:review_weight;not null;default:1" json:"review_weight,omitempty"`
 }
@@ -0,0 +1,724 @@
+package repository
+
+import (
+	"PR/apperr"
+	"PR/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+type MemoryRepository struct {
+	mu    *sync.RWMutex
+	state *memoryState
+	inTx  bool
+}
+
+type memoryState struct {
+	teams map[string]models.Team
+	users map[string]models.User
+	prs   map[string]models.PullRequest
+	audit []models.AuditEvent
+
+	idempotency map[string]models.IdempotencyRecord
+
+	lastReviewerID int64
+}
+
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		mu: &sync.RWMutex{},
+		state: &memoryState{
+			teams: make(map[string]models.Team),
+			users: make(map[string]models.User),
+			prs:   make(map[string]models.PullRequest),
+
+			idempotency: make(map[string]models.IdempotencyRecord),
+		},
+	}
+}
+
+// Transaction выполняет fn над копией состояния под эксклюзивной блокировкой
+// и подменяет состояние только если fn завершилась без ошибки.
+func (m *MemoryRepository) Transaction(fn func(Store) error) error {
+	if !m.inTx {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+	}
+
+	tx := &MemoryRepository{mu: m.mu, state: m.state.clone(), inTx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	*m.state = *tx.state
+	return nil
+}
+
+func (m *MemoryRepository) CreateTeam(team models.Team) error {
+	defer m.lock()()
+
+	if _, ok := m.state.teams[team.TeamName]; ok {
+		return apperr.AlreadyExists("TEAM_EXISTS", team.TeamName+" already exists")
+	}
+
+	members := team.Members
+	team.Members = nil
+	m.state.teams[team.TeamName] = team
+	for _, member := range members {
+		member.TeamName = team.TeamName
+		if member.ReviewWeight == 0 {
+			member.ReviewWeight = 1
+		}
+		m.state.users[member.UserId] = member
+	}
+	return nil
+}
+
+func (m *MemoryRepository) UpdateTeam(team *models.Team) error {
+	defer m.lock()()
+
+	stored := *team
+	stored.Members = nil
+	m.state.teams[team.TeamName] = stored
+	return nil
+}
+
+func (m *MemoryRepository) RenameTeam(oldName, newName string) error {
+	defer m.lock()()
+
+	team, ok := m.state.teams[oldName]
+	if !ok {
+		return apperr.NotFound("team not found")
+	}
+	if _, ok := m.state.teams[newName]; ok {
+		return apperr.AlreadyExists("TEAM_EXISTS", newName+" already exists")
+	}
+
+	delete(m.state.teams, oldName)
+	team.TeamName = newName
+	m.state.teams[newName] = team
+	for id, user := range m.state.users {
+		if user.TeamName == oldName {
+			user.TeamName = newName
+			m.state.users[id] = user
+		}
+	}
+	return nil
+}
+
+func (m *MemoryRepository) DeleteTeam(teamName string) error {
+	defer m.lock()()
+
+	if _, ok := m.state.teams[teamName]; !ok {
+		return apperr.NotFound("team not found")
+	}
+
+	for id, user := range m.state.users {
+		if user.TeamName == teamName {
+			m.detachUser(id)
+		}
+	}
+	delete(m.state.teams, teamName)
+	return nil
+}
+
+func (m *MemoryRepository) GetTeam(teamName string) (*models.Team, error) {
+	defer m.rlock()()
+
+	team, ok := m.state.teams[teamName]
+	if !ok {
+		return nil, apperr.NotFound("team not found")
+	}
+
+	team.Members = m.filterUsers(func(u models.User) bool {
+		return u.TeamName == teamName
+	})
+	return &team, nil
+}
+
+// GetTeamForUpdate в памяти не отличается от GetTeam, как и GetPRForUpdate.
+func (m *MemoryRepository) GetTeamForUpdate(teamName string) (*models.Team, error) {
+	return m.GetTeam(teamName)
+}
+
+func (m *MemoryRepository) GetUser(userId string) (*models.User, error) {
+	defer m.rlock()()
+
+	user, ok := m.state.users[userId]
+	if !ok {
+		return nil, apperr.NotFound("user not found")
+	}
+	return &user, nil
+}
+
+func (m *MemoryRepository) CreateUser(user models.User) error {
+	defer m.lock()()
+
+	if _, ok := m.state.teams[user.TeamName]; !ok {
+		return apperr.NotFound("team not found")
+	}
+
+	if _, ok := m.state.users[user.UserId]; ok {
+		return apperr.AlreadyExists("USER_EXISTS", "user already exists")
+	}
+
+	if user.ReviewWeight == 0 {
+		user.ReviewWeight = 1
+	}
+
+	m.state.users[user.UserId] = user
+	return nil
+}
+
+func (m *MemoryRepository) UpdateUserActive(userId string, isActive bool) (*models.User, error) {
+	defer m.lock()()
+
+	user, ok := m.state.users[userId]
+	if !ok {
+		return nil, apperr.NotFound("user not found")
+	}
+
+	user.IsActive = isActive
+	m.state.users[userId] = user
+	return &user, nil
+}
+
+func (m *MemoryRepository) UpdateUserTeam(userId, teamName string) (*models.User, error) {
+	defer m.lock()()
+
+	if _, ok := m.state.teams[teamName]; !ok {
+		return nil, apperr.NotFound("team not found")
+	}
+
+	user, ok := m.state.users[userId]
+	if !ok {
+		return nil, apperr.NotFound("user not found")
+	}
+
+	user.TeamName = teamName
+	m.state.users[userId] = user
+	return &user, nil
+}
+
+func (m *MemoryRepository) DetachUser(userId string) error {
+	defer m.lock()()
+
+	if _, ok := m.state.users[userId]; !ok {
+		return apperr.NotFound("user not found")
+	}
+
+	m.detachUser(userId)
+	return nil
+}
+
+// detachUser убирает пользователя из команды и деактивирует его; назначения остаются.
+func (m *MemoryRepository) detachUser(userId string) {
+	user := m.state.users[userId]
+	user.TeamName = ""
+	user.IsActive = false
+	m.state.users[userId] = user
+}
+
+func (m *MemoryRepository) ReattachUser(user models.User) error {
+	defer m.lock()()
+
+	if existing, ok := m.state.users[user.UserId]; !ok || existing.TeamName != "" {
+		return apperr.AlreadyExists("USER_EXISTS", "user already exists")
+	}
+
+	m.state.users[user.UserId] = user
+	return nil
+}
+
+func (m *MemoryRepository) GetActiveTeamMembers(teamName string) ([]models.User, error) {
+	defer m.rlock()()
+
+	return m.filterUsers(func(u models.User) bool {
+		return u.TeamName == teamName && u.IsActive
+	}), nil
+}
+
+func (m *MemoryRepository) BulkDeactivateUsers(teamName string, excludeUserIDs []string) (int64, error) {
+	defer m.lock()()
+
+	excluded := make(map[string]bool, len(excludeUserIDs))
+	for _, id := range excludeUserIDs {
+		excluded[id] = true
+	}
+
+	var affected int64
+	for id, user := range m.state.users {
+		if user.TeamName != teamName || !user.IsActive || excluded[id] {
+			continue
+		}
+		user.IsActive = false
+		m.state.users[id] = user
+		affected++
+	}
+	return affected, nil
+}
+
+func (m *MemoryRepository) CreatePR(pr models.PullRequest) error {
+	defer m.lock()()
+
+	if _, ok := m.state.users[pr.AuthorID]; !ok {
+		return apperr.NotFound("user not found")
+	}
+
+	if _, ok := m.state.prs[pr.PullRequestID]; ok {
+		return apperr.AlreadyExists("PR_EXISTS", "PR id already exists")
+	}
+
+	if pr.Status == "" {
+		pr.Status = models.StatusOpen
+	}
+	m.assignReviewerIDs(&pr)
+	m.state.prs[pr.PullRequestID] = clonePR(pr)
+	return nil
+}
+
+func (m *MemoryRepository) GetPR(prID string) (*models.PullRequest, error) {
+	defer m.rlock()()
+
+	pr, ok := m.state.prs[prID]
+	if !ok {
+		return nil, apperr.NotFound("PR not found")
+	}
+
+	pr = clonePR(pr)
+	return &pr, nil
+}
+
+// GetPRForUpdate в памяти не отличается от GetPR: транзакция и так
+// выполняется под эксклюзивной блокировкой всего хранилища.
+func (m *MemoryRepository) GetPRForUpdate(prID string) (*models.PullRequest, error) {
+	return m.GetPR(prID)
+}
+
+func (m *MemoryRepository) UpdatePR(pr *models.PullRequest) error {
+	defer m.lock()()
+
+	m.assignReviewerIDs(pr)
+	m.state.prs[pr.PullRequestID] = clonePR(*pr)
+	return nil
+}
+
+// assignReviewerIDs выдаёт ID новым назначениям, как автоинкремент pr_reviewers.id.
+func (m *MemoryRepository) assignReviewerIDs(pr *models.PullRequest) {
+	for i := range pr.Reviewers {
+		if pr.Reviewers[i].ID == 0 {
+			m.state.lastReviewerID++
+			pr.Reviewers[i].ID = m.state.lastReviewerID
+		}
+	}
+}
+
+func (m *MemoryRepository) GetPRStatus(PRId string) (models.PRStatus, error) {
+	defer m.rlock()()
+
+	pr, ok := m.state.prs[PRId]
+	if !ok {
+		return models.StatusNotFound, apperr.NotFound("PR not found")
+	}
+	return pr.Status, nil
+}
+
+func (m *MemoryRepository) GetPRsByReviewer(userID string) ([]models.PullRequest, error) {
+	defer m.rlock()()
+
+	return m.filterPRs(func(pr models.PullRequest) bool {
+		return pr.HasReviewer(userID)
+	}), nil
+}
+
+func (m *MemoryRepository) GetPRsEverAssigned(userID string) ([]models.PullRequest, error) {
+	defer m.rlock()()
+
+	return m.filterPRs(func(pr models.PullRequest) bool {
+		for _, reviewer := range pr.Reviewers {
+			if reviewer.UserID == userID {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (m *MemoryRepository) GetOpenPRsByReviewers(userIDs []string) ([]models.PullRequest, error) {
+	defer m.rlock()()
+
+	return m.filterPRs(func(pr models.PullRequest) bool {
+		if pr.Status != models.StatusOpen {
+			return false
+		}
+		for _, userID := range userIDs {
+			if pr.HasReviewer(userID) {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func (m *MemoryRepository) GetOpenPRsByAuthors(userIDs []string) ([]models.PullRequest, error) {
+	defer m.rlock()()
+
+	authors := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		authors[id] = true
+	}
+
+	return m.filterPRs(func(pr models.PullRequest) bool {
+		return pr.Status == models.StatusOpen && authors[pr.AuthorID]
+	}), nil
+}
+
+func (m *MemoryRepository) GetPRsByAuthors(userIDs []string) ([]models.PullRequest, error) {
+	defer m.rlock()()
+
+	authors := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		authors[id] = true
+	}
+
+	return m.filterPRs(func(pr models.PullRequest) bool {
+		return authors[pr.AuthorID]
+	}), nil
+}
+
+func (m *MemoryRepository) CountOpenReviews(userIDs []string) (map[string]int, error) {
+	defer m.rlock()()
+
+	counts := make(map[string]int, len(userIDs))
+	for _, pr := range m.state.prs {
+		if pr.Status != models.StatusOpen {
+			continue
+		}
+		for _, userID := range userIDs {
+			if pr.HasReviewer(userID) {
+				counts[userID]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (m *MemoryRepository) CountReviews(userIDs []string) (map[string]models.ReviewCounts, error) {
+	defer m.rlock()()
+
+	wanted := make(map[string]bool, len(userIDs))
+	for _, id := range userIDs {
+		wanted[id] = true
+	}
+
+	counts := make(map[string]models.ReviewCounts, len(userIDs))
+	for _, pr := range m.state.prs {
+		for _, reviewerID := range pr.AssignedReviewers {
+			if !wanted[reviewerID] {
+				continue
+			}
+			c := counts[reviewerID]
+			if pr.Status == models.StatusOpen {
+				c.Open++
+			} else {
+				c.Completed++
+			}
+			counts[reviewerID] = c
+		}
+	}
+	return counts, nil
+}
+
+func (m *MemoryRepository) CountOpenReviewsByTeam() (map[string]int, error) {
+	defer m.rlock()()
+
+	counts := make(map[string]int, len(m.state.teams))
+	for teamName := range m.state.teams {
+		counts[teamName] = 0
+	}
+	for _, pr := range m.state.prs {
+		if pr.Status != models.StatusOpen {
+			continue
+		}
+		for _, reviewerID := range pr.AssignedReviewers {
+			if user, ok := m.state.users[reviewerID]; ok {
+				counts[user.TeamName]++
+			}
+		}
+	}
+	return counts, nil
+}
+
+func (m *MemoryRepository) GetLastAssignedReviewer(teamName string) (string, error) {
+	defer m.rlock()()
+
+	var last *models.PRReviewer
+	for _, pr := range m.state.prs {
+		for i, reviewer := range pr.Reviewers {
+			if user, ok := m.state.users[reviewer.UserID]; !ok || user.TeamName != teamName {
+				continue
+			}
+			if last == nil || reviewer.AssignedAt.After(last.AssignedAt) ||
+				reviewer.AssignedAt.Equal(last.AssignedAt) && reviewer.ID > last.ID {
+				last = &pr.Reviewers[i]
+			}
+		}
+	}
+
+	if last == nil {
+		return "", nil
+	}
+	return last.UserID, nil
+}
+
+func (m *MemoryRepository) GetMergeTimings(teamName string, from, to *time.Time) ([]models.MergeTiming, error) {
+	defer m.rlock()()
+
+	timings := []models.MergeTiming{}
+	for _, pr := range m.state.prs {
+		if pr.Status != models.StatusMerged || pr.MergedAt == nil || !inRange(*pr.MergedAt, from, to) {
+			continue
+		}
+		team := m.state.users[pr.AuthorID].TeamName
+		if teamName != "" && team != teamName {
+			continue
+		}
+		timings = append(timings, models.MergeTiming{
+			PullRequestID: pr.PullRequestID,
+			AuthorID:      pr.AuthorID,
+			TeamName:      team,
+			CreatedAt:     pr.CreatedAt,
+			MergedAt:      *pr.MergedAt,
+		})
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		return timings[i].MergedAt.Before(timings[j].MergedAt)
+	})
+	return timings, nil
+}
+
+func (m *MemoryRepository) GetDecisionTimings(teamName string, from, to *time.Time) ([]models.DecisionTiming, error) {
+	defer m.rlock()()
+
+	timings := []models.DecisionTiming{}
+	for _, pr := range m.state.prs {
+		for _, reviewer := range pr.Reviewers {
+			if reviewer.FirstDecidedAt == nil || !inRange(*reviewer.FirstDecidedAt, from, to) {
+				continue
+			}
+			team := m.state.users[reviewer.UserID].TeamName
+			if teamName != "" && team != teamName {
+				continue
+			}
+			timings = append(timings, models.DecisionTiming{
+				UserID:         reviewer.UserID,
+				TeamName:       team,
+				AssignedAt:     reviewer.AssignedAt,
+				FirstDecidedAt: *reviewer.FirstDecidedAt,
+			})
+		}
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		return timings[i].FirstDecidedAt.Before(timings[j].FirstDecidedAt)
+	})
+	return timings, nil
+}
+
+func inRange(t time.Time, from, to *time.Time) bool {
+	return (from == nil || !t.Before(*from)) && (to == nil || t.Before(*to))
+}
+
+func (m *MemoryRepository) AddAuditEvent(event *models.AuditEvent) error {
+	defer m.lock()()
+
+	event.ID = int64(len(m.state.audit)) + 1
+	m.state.audit = append(m.state.audit, *event)
+	return nil
+}
+
+func (m *MemoryRepository) ListAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, error) {
+	defer m.rlock()()
+
+	events := []models.AuditEvent{}
+	for i := range m.state.audit {
+		if filter.Limit > 0 && len(events) >= filter.Limit {
+			break
+		}
+
+		event := m.state.audit[i]
+		if filter.Descending {
+			event = m.state.audit[len(m.state.audit)-1-i]
+		}
+		if filter.AfterID > 0 && (filter.Descending && event.ID >= filter.AfterID ||
+			!filter.Descending && event.ID <= filter.AfterID) {
+			continue
+		}
+		if filter.EntityType != "" && event.EntityType != filter.EntityType ||
+			filter.EntityID != "" && event.EntityID != filter.EntityID ||
+			filter.ActorID != "" && event.ActorID != filter.ActorID ||
+			!inRange(event.CreatedAt, filter.From, filter.To) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (m *MemoryRepository) CreateIdempotencyRecord(record models.IdempotencyRecord) error {
+	defer m.lock()()
+
+	for id, existing := range m.state.idempotency {
+		if !existing.ExpiresAt.After(record.CreatedAt) {
+			delete(m.state.idempotency, id)
+		}
+	}
+
+	id := idempotencyID(record.Key, record.Route)
+	if _, ok := m.state.idempotency[id]; ok {
+		return apperr.AlreadyExists("IDEMPOTENCY_KEY_EXISTS", "idempotency key is already in use")
+	}
+	m.state.idempotency[id] = record
+	return nil
+}
+
+func (m *MemoryRepository) GetIdempotencyRecord(key, route string, now time.Time) (*models.IdempotencyRecord, error) {
+	defer m.rlock()()
+
+	record, ok := m.state.idempotency[idempotencyID(key, route)]
+	if !ok || !record.ExpiresAt.After(now) {
+		return nil, apperr.NotFound("idempotency key not found")
+	}
+	return &record, nil
+}
+
+func (m *MemoryRepository) CompleteIdempotencyRecord(key, route string, statusCode int, body []byte, expiresAt time.Time) error {
+	defer m.lock()()
+
+	id := idempotencyID(key, route)
+	record, ok := m.state.idempotency[id]
+	if !ok {
+		return apperr.NotFound("idempotency key not found")
+	}
+	record.StatusCode = statusCode
+	record.ResponseBody = append([]byte(nil), body...)
+	record.ExpiresAt = expiresAt
+	m.state.idempotency[id] = record
+	return nil
+}
+
+func (m *MemoryRepository) DeleteIdempotencyRecord(key, route string) error {
+	defer m.lock()()
+
+	delete(m.state.idempotency, idempotencyID(key, route))
+	return nil
+}
+
+func idempotencyID(key, route string) string {
+	return route + " " + key
+}
+
+func (m *MemoryRepository) lock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.Lock()
+	return m.mu.Unlock
+}
+
+func (m *MemoryRepository) rlock() func() {
+	if m.inTx {
+		return func() {}
+	}
+	m.mu.RLock()
+	return m.mu.RUnlock
+}
+
+func (s *memoryState) clone() *memoryState {
+	c := &memoryState{
+		teams: make(map[string]models.Team, len(s.teams)),
+		users: make(map[string]models.User, len(s.users)),
+		prs:   make(map[string]models.PullRequest, len(s.prs)),
+	}
+	for k, v := range s.teams {
+		c.teams[k] = v
+	}
+	for k, v := range s.users {
+		c.users[k] = v
+	}
+	for k, v := range s.prs {
+		c.prs[k] = clonePR(v)
+	}
+	// Журнал только дополняется, поэтому достаточно ограничить ёмкость:
+	// append в транзакции не затронет общий массив.
+	c.audit = s.audit[:len(s.audit):len(s.audit)]
+	c.lastReviewerID = s.lastReviewerID
+	c.idempotency = make(map[string]models.IdempotencyRecord, len(s.idempotency))
+	for k, v := range s.idempotency {
+		c.idempotency[k] = v
+	}
+	return c
+}
+
+func (m *MemoryRepository) filterUsers(match func(models.User) bool) []models.User {
+	users := []models.User{}
+	for _, id := range sortedKeys(m.state.users) {
+		if user := m.state.users[id]; match(user) {
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+func (m *MemoryRepository) filterPRs(match func(models.PullRequest) bool) []models.PullRequest {
+	prs := []models.PullRequest{}
+	for _, pr := range m.state.prs {
+		if match(pr) {
+			prs = append(prs, clonePR(pr))
+		}
+	}
+	sort.Slice(prs, func(i, j int) bool {
+		if prs[i].CreatedAt.Equal(prs[j].CreatedAt) {
+			return prs[i].PullRequestID < prs[j].PullRequestID
+		}
+		return prs[i].CreatedAt.Before(prs[j].CreatedAt)
+	})
+	return prs
+}
+
+func clonePR(pr models.PullRequest) models.PullRequest {
+	reviewers := make([]models.PRReviewer, len(pr.Reviewers))
+	for i, reviewer := range pr.Reviewers {
+		reviewer.DecidedAt = cloneTime(reviewer.DecidedAt)
+		reviewer.FirstDecidedAt = cloneTime(reviewer.FirstDecidedAt)
+		reviewer.RemovedAt = cloneTime(reviewer.RemovedAt)
+		if reviewer.ReplacedBy != nil {
+			replacedBy := *reviewer.ReplacedBy
+			reviewer.ReplacedBy = &replacedBy
+		}
+		reviewer.Reviewer = nil
+		reviewers[i] = reviewer
+	}
+	pr.Reviewers = reviewers
+	pr.MergedAt = cloneTime(pr.MergedAt)
+	pr.ClosedAt = cloneTime(pr.ClosedAt)
+	pr.SyncReviewers()
+	return pr
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,10 +1,13 @@
 package repository
 
 import (
+	"PR/apperr"
 	"PR/models"
 	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository struct {
@@ -15,10 +18,19 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
+func (r *Repository) Transaction(fn func(Store) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&Repository{db: tx})
+	})
+}
+
 func (r *Repository) CreateTeam(team models.Team) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&team).Error; err != nil {
-			return errors.New("Команда с таким названием уже существует")
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return apperr.AlreadyExists("TEAM_EXISTS", team.TeamName+" already exists")
+			}
+			return err
 		}
 
 		for i := range team.Members {
@@ -36,15 +48,80 @@ func (r *Repository) CreateTeam(team models.Team) error {
 func (r *Repository) GetTeam(teamName string) (*models.Team, error) {
 	var team models.Team
 	if err := r.db.Preload("Members").Where("team_name = ?", teamName).First(&team).Error; err != nil {
-		return nil, errors.New("Команда не найдена")
+		return nil, notFound(err, "team not found")
+	}
+	return &team, nil
+}
+
+// GetTeamForUpdate читает команду с блокировкой строки до конца транзакции, чтобы
+// параллельные изменения настроек одной команды не затирали друг друга.
+func (r *Repository) GetTeamForUpdate(teamName string) (*models.Team, error) {
+	var team models.Team
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("team_name = ?", teamName).First(&team).Error; err != nil {
+		return nil, notFound(err, "team not found")
+	}
+
+	if err := r.db.Where("team_name = ?", teamName).Find(&team.Members).Error; err != nil {
+		return nil, err
 	}
 	return &team, nil
 }
 
+func (r *Repository) UpdateTeam(team *models.Team) error {
+	return r.db.Omit("Members").Save(team).Error
+}
+
+// RenameTeam переносит команду и её участников под новое имя. Первичный ключ
+// teams не обновляется каскадно, поэтому создаётся новая строка, а старая удаляется.
+func (r *Repository) RenameTeam(oldName, newName string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var team models.Team
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("team_name = ?", oldName).First(&team).Error; err != nil {
+			return notFound(err, "team not found")
+		}
+
+		team.TeamName = newName
+		if err := tx.Omit("Members").Create(&team).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return apperr.AlreadyExists("TEAM_EXISTS", newName+" already exists")
+			}
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("team_name = ?", oldName).
+			Update("team_name", newName).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("team_name = ?", oldName).Delete(&models.Team{}).Error
+	})
+}
+
+// DeleteTeam удаляет команду, а её участников отвязывает и деактивирует (см. DetachUser).
+func (r *Repository) DeleteTeam(teamName string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("team_name = ?", teamName).
+			Updates(map[string]any{"team_name": nil, "is_active": false}).Error; err != nil {
+			return err
+		}
+
+		result := tx.Where("team_name = ?", teamName).Delete(&models.Team{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return apperr.NotFound("team not found")
+		}
+		return nil
+	})
+}
+
 func (r *Repository) GetUser(userId string) (*models.User, error) {
 	var user models.User
 	if err := r.db.Where("user_id = ?", userId).First(&user).Error; err != nil {
-		return nil, errors.New("Таких у нас нет")
+		return nil, notFound(err, "user not found")
 	}
 	return &user, nil
 }
@@ -52,94 +129,365 @@ func (r *Repository) GetUser(userId string) (*models.User, error) {
 func (r *Repository) CreateUser(user models.User) error {
 	var team models.Team
 	if err := r.db.Where("team_name = ?", user.TeamName).First(&team).Error; err != nil {
-		return errors.New("Нет такой команды")
+		return notFound(err, "team not found")
 	}
 
 	var existingUser models.User
 	if r.db.Where("user_id = ?", user.UserId).First(&existingUser).RowsAffected > 0 {
-		return errors.New("Такой уже существует")
+		return apperr.AlreadyExists("USER_EXISTS", "user already exists")
 	}
 
-	return r.db.Create(user).Error
+	if err := r.db.Create(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperr.AlreadyExists("USER_EXISTS", "user already exists")
+		}
+		return err
+	}
+	return nil
 }
 
 func (r *Repository) UpdateUserActive(userId string, isActive bool) (*models.User, error) {
-	user, err := r.GetUser(userId)
-	if err != nil {
-		return nil, errors.New("Таких у нас нет")
+	return r.updateUserColumn(userId, "is_active", isActive)
+}
+
+func (r *Repository) UpdateUserTeam(userId, teamName string) (*models.User, error) {
+	var team models.Team
+	if err := r.db.Where("team_name = ?", teamName).First(&team).Error; err != nil {
+		return nil, notFound(err, "team not found")
 	}
 
-	user.IsActive = isActive
-	if err := r.db.Save(user).Error; err != nil {
-		return nil, errors.New("Не удалось обновить статус активности")
+	return r.updateUserColumn(userId, "team_name", teamName)
+}
+
+// updateUserColumn меняет одну колонку пользователя, не перезаписывая остальные:
+// сохранение всей прочитанной строки затёрло бы параллельное изменение другого поля.
+func (r *Repository) updateUserColumn(userId, column string, value any) (*models.User, error) {
+	result := r.db.Model(&models.User{}).Where("user_id = ?", userId).Update(column, value)
+	if result.Error != nil {
+		return nil, result.Error
 	}
-	return user, nil
+	if result.RowsAffected == 0 {
+		return nil, apperr.NotFound("user not found")
+	}
+	return r.GetUser(userId)
 }
 
-func (r *Repository) DeleteUser(userId string) error {
-	result := r.db.Where("user_id = ?", userId).Delete(&models.User{})
+// DetachUser убирает пользователя из команды и деактивирует его. Строка пользователя
+// остаётся, чтобы сохранились его назначения ревьюером и авторство PR.
+func (r *Repository) DetachUser(userId string) error {
+	result := r.db.Model(&models.User{}).Where("user_id = ?", userId).
+		Updates(map[string]any{"team_name": nil, "is_active": false})
 	if result.Error != nil {
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
-		return errors.New("Таких и не было")
+		return apperr.NotFound("user not found")
 	}
 
 	return nil
 }
 
+// ReattachUser возвращает пользователя, ранее убранного из команды, в user.TeamName
+// с новыми именем, активностью и весом. Если пользователь уже состоит в команде,
+// возвращается USER_EXISTS.
+func (r *Repository) ReattachUser(user models.User) error {
+	result := r.db.Model(&models.User{}).Where("user_id = ? AND team_name IS NULL", user.UserId).
+		Updates(map[string]any{
+			"team_name":     user.TeamName,
+			"username":      user.UserName,
+			"is_active":     user.IsActive,
+			"review_weight": user.ReviewWeight,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return apperr.AlreadyExists("USER_EXISTS", "user already exists")
+	}
+	return nil
+}
+
 func (r *Repository) GetActiveTeamMembers(teamName string) ([]models.User, error) {
 	var users []models.User
 	if err := r.db.Where("team_name = ? AND is_active = ?", teamName, true).Find(&users).Error; err != nil {
-		return nil, errors.New("Нет такой команды")
+		return nil, err
 	}
 
 	return users, nil
 }
 
+// CreatePR полагается на первичный ключ: если PR с таким id вставили параллельно,
+// нарушение уникальности превращается в PR_EXISTS, а не во внутреннюю ошибку.
 func (r *Repository) CreatePR(pr models.PullRequest) error {
-	if _, err := r.GetUser(pr.AuthorID); err != nil {
-		return errors.New("Автора не существует")
+	if err := r.db.Create(&pr).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperr.AlreadyExists("PR_EXISTS", "PR id already exists")
+		}
+		return err
 	}
+	return nil
+}
 
-	var existingPR models.PullRequest
-	if err := r.db.Where("pull_request_id = ?", pr.PullRequestID).First(&existingPR).Error; err == nil {
-		return errors.New("PR уже существует")
+func (r *Repository) GetPR(prID string) (*models.PullRequest, error) {
+	var pr models.PullRequest
+	if err := r.db.Preload("Reviewers", orderReviewers).
+		Where("pull_request_id = ?", prID).First(&pr).Error; err != nil {
+		return nil, notFound(err, "PR not found")
 	}
 
-	return r.db.Create(pr).Error
+	pr.SyncReviewers()
+	return &pr, nil
 }
 
-func (r *Repository) GetPR(prID string) (*models.PullRequest, error) {
+// GetPRForUpdate читает PR с блокировкой строки (SELECT ... FOR UPDATE) до конца транзакции.
+// Все изменения PR и его ревьюеров идут через эту блокировку, поэтому параллельные
+// запросы к одному PR выполняются по очереди и не затирают изменения друг друга.
+func (r *Repository) GetPRForUpdate(prID string) (*models.PullRequest, error) {
 	var pr models.PullRequest
-	if err := r.db.Where("pull_request_id = ?", prID).First(&pr).Error; err != nil {
-		return nil, errors.New("PR с таким ID не существует")
+	if err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("pull_request_id = ?", prID).First(&pr).Error; err != nil {
+		return nil, notFound(err, "PR not found")
 	}
 
+	if err := orderReviewers(r.db).
+		Where("pull_request_id = ?", prID).Find(&pr.Reviewers).Error; err != nil {
+		return nil, err
+	}
+
+	pr.SyncReviewers()
 	return &pr, nil
 }
 
+// UpdatePR сохраняет PR и его назначения: новые (без ID) вставляются,
+// существующие обновляются по ID.
 func (r *Repository) UpdatePR(pr *models.PullRequest) error {
-	return r.db.Save(pr).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Reviewers").Save(pr).Error; err != nil {
+			return err
+		}
+
+		for i := range pr.Reviewers {
+			pr.Reviewers[i].PullRequestID = pr.PullRequestID
+			if err := tx.Omit("Reviewer").Save(&pr.Reviewers[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (r *Repository) GetPRStatus(PRId string) (models.PRStatus, error) {
 	var status models.PRStatus
-	if err := r.db.Where("pull_request_id = ?", PRId).First(&status).Error; err != nil {
-		return models.StatusNotFound, errors.New("Нет PR с таким ID")
+	if err := r.db.Model(&models.PullRequest{}).Select("status").
+		Where("pull_request_id = ?", PRId).Take(&status).Error; err != nil {
+		return models.StatusNotFound, notFound(err, "PR not found")
 	}
 
 	return status, nil
 }
 
 func (r *Repository) GetPRsByReviewer(userID string) ([]models.PullRequest, error) {
+	assigned := r.db.Model(&models.PRReviewer{}).Select("pull_request_id").
+		Where("user_id = ? AND removed_at IS NULL", userID)
+
+	return r.findPRs(r.db.Where("pull_request_id IN (?)", assigned))
+}
+
+// GetPRsEverAssigned возвращает PR, на которые пользователь был назначен когда-либо,
+// включая те, с которых его сняли. Все строки назначений доступны в PullRequest.Reviewers.
+func (r *Repository) GetPRsEverAssigned(userID string) ([]models.PullRequest, error) {
+	assigned := r.db.Model(&models.PRReviewer{}).Select("pull_request_id").Where("user_id = ?", userID)
+
+	return r.findPRs(r.db.Where("pull_request_id IN (?)", assigned))
+}
+
+func (r *Repository) GetOpenPRsByReviewers(userIDs []string) ([]models.PullRequest, error) {
+	if len(userIDs) == 0 {
+		return []models.PullRequest{}, nil
+	}
+
+	assigned := r.db.Model(&models.PRReviewer{}).Select("pull_request_id").
+		Where("user_id IN ? AND removed_at IS NULL", userIDs)
+
+	return r.findPRs(r.db.Where("status = ? AND pull_request_id IN (?)", models.StatusOpen, assigned))
+}
+
+func (r *Repository) GetOpenPRsByAuthors(userIDs []string) ([]models.PullRequest, error) {
+	if len(userIDs) == 0 {
+		return []models.PullRequest{}, nil
+	}
+
+	return r.findPRs(r.db.Where("status = ? AND author_id IN ?", models.StatusOpen, userIDs))
+}
+
+func (r *Repository) GetPRsByAuthors(userIDs []string) ([]models.PullRequest, error) {
+	if len(userIDs) == 0 {
+		return []models.PullRequest{}, nil
+	}
+
+	return r.findPRs(r.db.Where("author_id IN ?", userIDs))
+}
+
+func (r *Repository) CountOpenReviews(userIDs []string) (map[string]int, error) {
+	counts := make(map[string]int, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		UserID string
+		Open   int
+	}
+	if err := r.db.Raw(`SELECT r.user_id, COUNT(*) AS open
+		FROM pr_reviewers r
+		JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+		WHERE p.status = ? AND r.removed_at IS NULL AND r.user_id IN ?
+		GROUP BY r.user_id`, models.StatusOpen, userIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.UserID] = row.Open
+	}
+	return counts, nil
+}
+
+// CountReviews одним запросом считает действующие назначения каждого из userIDs на открытые
+// и на завершённые PR. Пользователи без назначений в результат не попадают.
+func (r *Repository) CountReviews(userIDs []string) (map[string]models.ReviewCounts, error) {
+	counts := make(map[string]models.ReviewCounts, len(userIDs))
+	if len(userIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		UserID    string
+		Open      int
+		Completed int
+	}
+	if err := r.db.Raw(`SELECT r.user_id,
+			COUNT(*) FILTER (WHERE p.status = ?) AS open,
+			COUNT(*) FILTER (WHERE p.status <> ?) AS completed
+		FROM pr_reviewers r
+		JOIN pull_requests p ON p.pull_request_id = r.pull_request_id
+		WHERE r.removed_at IS NULL AND r.user_id IN ?
+		GROUP BY r.user_id`, models.StatusOpen, models.StatusOpen, userIDs).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.UserID] = models.ReviewCounts{Open: row.Open, Completed: row.Completed}
+	}
+	return counts, nil
+}
+
+// CountOpenReviewsByTeam считает действующие назначения на открытые PR по командам ревьюеров.
+// Команды без открытых ревью возвращаются с нулём.
+func (r *Repository) CountOpenReviewsByTeam() (map[string]int, error) {
+	var rows []struct {
+		TeamName string
+		Open     int
+	}
+	if err := r.db.Raw(`SELECT t.team_name, COUNT(p.pull_request_id) AS open
+		FROM teams t
+		LEFT JOIN users u ON u.team_name = t.team_name
+		LEFT JOIN pr_reviewers r ON r.user_id = u.user_id AND r.removed_at IS NULL
+		LEFT JOIN pull_requests p ON p.pull_request_id = r.pull_request_id AND p.status = ?
+		GROUP BY t.team_name`, models.StatusOpen).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.TeamName] = row.Open
+	}
+	return counts, nil
+}
+
+// GetLastAssignedReviewer возвращает user_id участника teamName, назначенного ревьюером
+// последним (включая уже снятые назначения), или пустую строку, если назначений не было.
+func (r *Repository) GetLastAssignedReviewer(teamName string) (string, error) {
+	var userIDs []string
+	if err := r.db.Raw(`SELECT r.user_id
+		FROM pr_reviewers r
+		JOIN users u ON u.user_id = r.user_id
+		WHERE u.team_name = ?
+		ORDER BY r.assigned_at DESC, r.id DESC
+		LIMIT 1`, teamName).Scan(&userIDs).Error; err != nil {
+		return "", err
+	}
+
+	if len(userIDs) == 0 {
+		return "", nil
+	}
+	return userIDs[0], nil
+}
+
+// GetMergeTimings возвращает PR, слитые в интервале [from, to), вместе с текущей командой
+// автора; границы необязательны. Если задан teamName, только PR авторов этой команды.
+func (r *Repository) GetMergeTimings(teamName string, from, to *time.Time) ([]models.MergeTiming, error) {
+	query := r.db.Table("pull_requests p").
+		Select("p.pull_request_id, p.author_id, COALESCE(u.team_name, '') AS team_name, p.created_at, p.merged_at").
+		Joins("LEFT JOIN users u ON u.user_id = p.author_id").
+		Where("p.status = ? AND p.merged_at IS NOT NULL", models.StatusMerged)
+	if teamName != "" {
+		query = query.Where("u.team_name = ?", teamName)
+	}
+	if from != nil {
+		query = query.Where("p.merged_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("p.merged_at < ?", *to)
+	}
+
+	var timings []models.MergeTiming
+	if err := query.Order("p.merged_at").Scan(&timings).Error; err != nil {
+		return nil, err
+	}
+	return timings, nil
+}
+
+// GetDecisionTimings возвращает назначения, по которым первое решение принято
+// в интервале [from, to), включая уже снятых ревьюеров, вместе с текущей командой
+// ревьюера. Если задан teamName, только ревьюеров этой команды.
+func (r *Repository) GetDecisionTimings(teamName string, from, to *time.Time) ([]models.DecisionTiming, error) {
+	query := r.db.Table("pr_reviewers r").
+		Select("r.user_id, COALESCE(u.team_name, '') AS team_name, r.assigned_at, r.first_decided_at").
+		Joins("LEFT JOIN users u ON u.user_id = r.user_id").
+		Where("r.first_decided_at IS NOT NULL")
+	if teamName != "" {
+		query = query.Where("u.team_name = ?", teamName)
+	}
+	if from != nil {
+		query = query.Where("r.first_decided_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("r.first_decided_at < ?", *to)
+	}
+
+	var timings []models.DecisionTiming
+	if err := query.Order("r.first_decided_at").Scan(&timings).Error; err != nil {
+		return nil, err
+	}
+	return timings, nil
+}
+
+func (r *Repository) findPRs(query *gorm.DB) ([]models.PullRequest, error) {
 	var prs []models.PullRequest
-	if err := r.db.Where("? = ANY(assigned_reviewers)", userID).Find(&prs).Error; err != nil {
+	if err := query.Preload("Reviewers", orderReviewers).Order("created_at").Find(&prs).Error; err != nil {
 		return nil, err
 	}
+
+	for i := range prs {
+		prs[i].SyncReviewers()
+	}
 	return prs, nil
 }
 
+func orderReviewers(db *gorm.DB) *gorm.DB {
+	return db.Order("assigned_at, id")
+}
+
 func (r *Repository) BulkDeactivateUsers(teamName string, excludeUserIDs []string) (int64, error) {
 	query := r.db.Model(&models.User{}).Where("team_name = ? AND is_active = ?", teamName, true)
 
@@ -150,3 +498,89 @@ func (r *Repository) BulkDeactivateUsers(teamName string, excludeUserIDs []strin
 	result := query.Update("is_active", false)
 	return result.RowsAffected, result.Error
 }
+
+func (r *Repository) AddAuditEvent(event *models.AuditEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *Repository) ListAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, error) {
+	query := r.db.Model(&models.AuditEvent{})
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	order := "id"
+	if filter.Descending {
+		order = "id DESC"
+		if filter.AfterID > 0 {
+			query = query.Where("id < ?", filter.AfterID)
+		}
+	} else if filter.AfterID > 0 {
+		query = query.Where("id > ?", filter.AfterID)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	events := []models.AuditEvent{}
+	if err := query.Order(order).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// CreateIdempotencyRecord резервирует ключ. Заодно удаляются просроченные записи,
+// в том числе прежняя запись с этим же ключом, если её срок истёк.
+func (r *Repository) CreateIdempotencyRecord(record models.IdempotencyRecord) error {
+	if err := r.db.Where("expires_at <= ?", record.CreatedAt).Delete(&models.IdempotencyRecord{}).Error; err != nil {
+		return err
+	}
+
+	if err := r.db.Create(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return apperr.AlreadyExists("IDEMPOTENCY_KEY_EXISTS", "idempotency key is already in use")
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *Repository) GetIdempotencyRecord(key, route string, now time.Time) (*models.IdempotencyRecord, error) {
+	var record models.IdempotencyRecord
+	if err := r.db.Where("idempotency_key = ? AND route = ? AND expires_at > ?", key, route, now).
+		First(&record).Error; err != nil {
+		return nil, notFound(err, "idempotency key not found")
+	}
+	return &record, nil
+}
+
+func (r *Repository) CompleteIdempotencyRecord(key, route string, statusCode int, body []byte, expiresAt time.Time) error {
+	return r.db.Model(&models.IdempotencyRecord{}).
+		Where("idempotency_key = ? AND route = ?", key, route).
+		Updates(map[string]any{"status_code": statusCode, "response_body": body, "expires_at": expiresAt}).Error
+}
+
+func (r *Repository) DeleteIdempotencyRecord(key, route string) error {
+	return r.db.Where("idempotency_key = ? AND route = ?", key, route).
+		Delete(&models.IdempotencyRecord{}).Error
+}
+
+// notFound превращает gorm.ErrRecordNotFound в доменную ошибку NOT_FOUND,
+// остальные ошибки (например, недоступность БД) пробрасывает как есть.
+func notFound(err error, message string) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return apperr.NotFound(message)
+	}
+	return err
+}
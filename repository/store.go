@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"PR/models"
+	"time"
+)
+
+type Store interface {
+	Transaction(fn func(Store) error) error
+
+	CreateTeam(team models.Team) error
+	GetTeam(teamName string) (*models.Team, error)
+	GetTeamForUpdate(teamName string) (*models.Team, error)
+	UpdateTeam(team *models.Team) error
+	RenameTeam(oldName, newName string) error
+	DeleteTeam(teamName string) error
+
+	GetUser(userId string) (*models.User, error)
+	CreateUser(user models.User) error
+	UpdateUserActive(userId string, isActive bool) (*models.User, error)
+	UpdateUserTeam(userId, teamName string) (*models.User, error)
+	DetachUser(userId string) error
+	ReattachUser(user models.User) error
+	GetActiveTeamMembers(teamName string) ([]models.User, error)
+	BulkDeactivateUsers(teamName string, excludeUserIDs []string) (int64, error)
+
+	CreatePR(pr models.PullRequest) error
+	GetPR(prID string) (*models.PullRequest, error)
+	GetPRForUpdate(prID string) (*models.PullRequest, error)
+	UpdatePR(pr *models.PullRequest) error
+	GetPRStatus(PRId string) (models.PRStatus, error)
+	GetPRsByReviewer(userID string) ([]models.PullRequest, error)
+	GetPRsEverAssigned(userID string) ([]models.PullRequest, error)
+	GetOpenPRsByReviewers(userIDs []string) ([]models.PullRequest, error)
+	GetOpenPRsByAuthors(userIDs []string) ([]models.PullRequest, error)
+	GetPRsByAuthors(userIDs []string) ([]models.PullRequest, error)
+	CountOpenReviews(userIDs []string) (map[string]int, error)
+	CountReviews(userIDs []string) (map[string]models.ReviewCounts, error)
+	CountOpenReviewsByTeam() (map[string]int, error)
+	GetLastAssignedReviewer(teamName string) (string, error)
+	GetMergeTimings(teamName string, from, to *time.Time) ([]models.MergeTiming, error)
+	GetDecisionTimings(teamName string, from, to *time.Time) ([]models.DecisionTiming, error)
+
+	AddAuditEvent(event *models.AuditEvent) error
+	ListAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, error)
+
+	CreateIdempotencyRecord(record models.IdempotencyRecord) error
+	GetIdempotencyRecord(key, route string, now time.Time) (*models.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(key, route string, statusCode int, body []byte, expiresAt time.Time) error
+	DeleteIdempotencyRecord(key, route string) error
+}
+
+var (
+	_ Store = (*Repository)(nil)
+	_ Store = (*MemoryRepository)(nil)
+)
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"PR/models"
+	"PR/repository"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// WithActor возвращает копию сервиса, изменения через которую записываются
+// в журнал аудита от имени actorID.
+func (rs *ReviewService) WithActor(actorID string) *ReviewService {
+	scoped := *rs
+	scoped.actor = actorID
+	return &scoped
+}
+
+func (rs *ReviewService) ListAuditEvents(filter models.AuditFilter) ([]models.AuditEvent, error) {
+	return rs.repo.ListAuditEvents(filter)
+}
+
+// audit добавляет событие в журнал через store, то есть в той же транзакции, что и само изменение.
+func (rs *ReviewService) audit(store repository.Store, action, entityType, entityID string, details map[string]any) error {
+	actor := rs.actor
+	if actor == "" {
+		actor = models.SystemActor
+	}
+
+	event := models.AuditEvent{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		ActorID:    actor,
+		CreatedAt:  time.Now(),
+	}
+	if details != nil {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			return err
+		}
+		event.Details = raw
+	}
+
+	return store.AddAuditEvent(&event)
+}
+
+func (rs *ReviewService) auditPR(store repository.Store, action string, pr *models.PullRequest, details map[string]any) error {
+	return rs.audit(store, action, models.AuditEntityPullRequest, pr.PullRequestID, details)
+}
+
+// GetPRTimeline восстанавливает историю PR по журналу аудита в порядке записи событий.
+func (rs *ReviewService) GetPRTimeline(prID string) ([]models.TimelineEntry, error) {
+	if _, err := rs.repo.GetPR(prID); err != nil {
+		return nil, err
+	}
+
+	events, err := rs.repo.ListAuditEvents(models.AuditFilter{
+		EntityType: models.AuditEntityPullRequest,
+		EntityID:   prID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := make([]models.TimelineEntry, 0, len(events))
+	for _, event := range events {
+		var entry models.TimelineEntry
+		if len(event.Details) > 0 {
+			if err := json.Unmarshal(event.Details, &entry); err != nil {
+				return nil, fmt.Errorf("decode audit event %d: %w", event.ID, err)
+			}
+		}
+		entry.At = event.CreatedAt
+		entry.Event = event.Action
+		entry.ActorID = event.ActorID
+		timeline = append(timeline, entry)
+	}
+	return timeline, nil
+}
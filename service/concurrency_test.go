@@ -0,0 +1,322 @@
+package service_test
+
+import (
+	"PR/apperr"
+	"PR/migrations"
+	"PR/models"
+	"PR/repository"
+	"PR/service"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Тесты гоняются на обоих хранилищах. Для Postgres нужна отдельная база,
+// которая очищается перед каждым тестом: TEST_DATABASE_DSN="host=... dbname=pr_review_test ...".
+var stores = []struct {
+	name string
+	open func(t *testing.T) repository.Store
+}{
+	{"memory", func(t *testing.T) repository.Store { return repository.NewMemoryRepository() }},
+	{"postgres", openPostgres},
+}
+
+func openPostgres(t *testing.T) repository.Store {
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if err := migrator.Up(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := db.Exec(`TRUNCATE teams, users, pull_requests, pr_reviewers, audit_events, idempotency_keys CASCADE`).Error; err != nil {
+		t.Fatalf("truncate: %v", err)
+	}
+	return repository.NewRepository(db)
+}
+
+// slowStore придерживает транзакцию после чтения PR для изменения, чтобы параллельные
+// запросы гарантированно пересекались даже на одном процессоре. Без блокировки строки
+// (или хранилища) они прочитали бы один и тот же PR и затёрли изменения друг друга.
+type slowStore struct {
+	repository.Store
+}
+
+func (s slowStore) Transaction(fn func(repository.Store) error) error {
+	return s.Store.Transaction(func(tx repository.Store) error {
+		return fn(slowStore{tx})
+	})
+}
+
+func (s slowStore) GetPRForUpdate(prID string) (*models.PullRequest, error) {
+	pr, err := s.Store.GetPRForUpdate(prID)
+	time.Sleep(time.Millisecond)
+	return pr, err
+}
+
+// setup создаёт команду из автора и members ревьюеров.
+func setup(t *testing.T, store repository.Store, members int) *service.ReviewService {
+	t.Helper()
+
+	rs := service.NewReviewService(slowStore{store}, service.NewRandom(1))
+	team := &models.Team{TeamName: "backend", Members: []models.User{{UserId: "author", UserName: "author", IsActive: true}}}
+	for i := 1; i <= members; i++ {
+		id := fmt.Sprintf("u%d", i)
+		team.Members = append(team.Members, models.User{UserId: id, UserName: id, IsActive: true})
+	}
+	if err := rs.CreateTeam(team, false); err != nil {
+		t.Fatalf("create team: %v", err)
+	}
+	return rs
+}
+
+func createPR(t *testing.T, rs *service.ReviewService, prID string) *models.PullRequest {
+	t.Helper()
+
+	pr, err := rs.CreatePR(prID, prID, "author", false)
+	if err != nil {
+		t.Fatalf("create PR: %v", err)
+	}
+	if len(pr.AssignedReviewers) != 2 {
+		t.Fatalf("expected 2 reviewers, got %v", pr.AssignedReviewers)
+	}
+	return pr
+}
+
+func errorCode(err error) string {
+	var appErr *apperr.Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
+// Каждая горутина переназначает одного из текущих ревьюеров; проигравший гонку
+// получает NOT_ASSIGNED и пробует снова. Если бы обновления терялись, итоговых строк
+// назначений было бы меньше, чем успешных переназначений, или активных ревьюеров не два.
+func TestConcurrentReassignsKeepEveryAssignment(t *testing.T) {
+	const workers = 8
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			repo := store.open(t)
+			rs := setup(t, repo, 6)
+			createPR(t, rs, "pr-1")
+
+			var wg sync.WaitGroup
+			errs := make(chan error, workers)
+			for i := 0; i < workers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					for attempt := 0; attempt < 100; attempt++ {
+						pr, err := repo.GetPR("pr-1")
+						if err != nil {
+							errs <- err
+							return
+						}
+
+						old := pr.AssignedReviewers[i%len(pr.AssignedReviewers)]
+						_, _, err = rs.ReassignReviewer("pr-1", old)
+						if err == nil {
+							return
+						}
+						if errorCode(err) != "NOT_ASSIGNED" {
+							errs <- err
+							return
+						}
+					}
+					errs <- fmt.Errorf("worker %d did not manage to reassign", i)
+				}(i)
+			}
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Fatal(err)
+			}
+
+			pr, err := repo.GetPR("pr-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pr.AssignedReviewers) != 2 || pr.AssignedReviewers[0] == pr.AssignedReviewers[1] {
+				t.Fatalf("expected 2 distinct active reviewers, got %v", pr.AssignedReviewers)
+			}
+			if len(pr.Reviewers) != 2+workers {
+				t.Fatalf("expected %d assignment rows, got %d", 2+workers, len(pr.Reviewers))
+			}
+
+			replaced := 0
+			for _, reviewer := range pr.Reviewers {
+				if reviewer.UserID == "author" {
+					t.Fatalf("author was assigned as reviewer")
+				}
+				if !reviewer.IsActive() {
+					if reviewer.ReplacedBy == nil {
+						t.Fatalf("removed assignment of %s has no replacement", reviewer.UserID)
+					}
+					replaced++
+				}
+			}
+			if replaced != workers {
+				t.Fatalf("expected %d replaced assignments, got %d", workers, replaced)
+			}
+
+			events, err := rs.ListAuditEvents(models.AuditFilter{
+				EntityType: models.AuditEntityPullRequest,
+				EntityID:   "pr-1",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			logged := 0
+			for _, event := range events {
+				if event.Action == models.AuditReviewerReplaced {
+					logged++
+				}
+			}
+			if logged != workers {
+				t.Fatalf("expected %d %s events, got %d", workers, models.AuditReviewerReplaced, logged)
+			}
+		})
+	}
+}
+
+// Переназначение, пришедшее одновременно со слиянием, либо успевает до него,
+// либо получает PR_MERGED; слияние проходит всегда.
+func TestReassignRacingMerge(t *testing.T) {
+	const rounds = 20
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			repo := store.open(t)
+			rs := setup(t, repo, 4)
+
+			for round := 0; round < rounds; round++ {
+				prID := fmt.Sprintf("pr-%d", round)
+				created := createPR(t, rs, prID)
+				old := created.AssignedReviewers[0]
+
+				var wg sync.WaitGroup
+				var reassignErr, mergeErr error
+				start := make(chan struct{})
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					<-start
+					_, _, reassignErr = rs.ReassignReviewer(prID, old)
+				}()
+				go func() {
+					defer wg.Done()
+					<-start
+					_, mergeErr = rs.MergePR(prID, false)
+				}()
+				close(start)
+				wg.Wait()
+
+				if mergeErr != nil {
+					t.Fatalf("%s: merge failed: %v", prID, mergeErr)
+				}
+
+				pr, err := repo.GetPR(prID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if pr.Status != models.StatusMerged {
+					t.Fatalf("%s: expected MERGED, got %s", prID, pr.Status)
+				}
+
+				switch {
+				case reassignErr == nil:
+					if pr.HasReviewer(old) || len(pr.AssignedReviewers) != 2 {
+						t.Fatalf("%s: reassign succeeded but reviewers are %v", prID, pr.AssignedReviewers)
+					}
+				case errorCode(reassignErr) == "PR_MERGED":
+					if !pr.HasReviewer(old) || len(pr.Reviewers) != 2 {
+						t.Fatalf("%s: reassign lost to merge but reviewers changed: %v", prID, pr.AssignedReviewers)
+					}
+				default:
+					t.Fatalf("%s: reassign failed with %v, expected success or PR_MERGED", prID, reassignErr)
+				}
+			}
+		})
+	}
+}
+
+// Массовая деактивация проходит по нескольким PR, пока параллельные переназначения
+// держат отдельные PR. С round_robin обе стороны блокируют и PR, и строку команды;
+// при разном порядке блокировок Postgres прервал бы одну из транзакций (deadlock).
+func TestBulkDeactivationRacingReassigns(t *testing.T) {
+	const prs = 6
+
+	for _, store := range stores {
+		t.Run(store.name, func(t *testing.T) {
+			repo := store.open(t)
+			rs := setup(t, repo, 6)
+			strategy := models.StrategyRoundRobin
+			if _, err := rs.UpdateTeamSettings("backend", models.TeamSettingsUpdate{AssignmentStrategy: &strategy}); err != nil {
+				t.Fatal(err)
+			}
+
+			var reviewers [][]string
+			for i := 0; i < prs; i++ {
+				reviewers = append(reviewers, createPR(t, rs, fmt.Sprintf("pr-%d", i)).AssignedReviewers)
+			}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, prs+1)
+			start := make(chan struct{})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if _, err := rs.BulkDeactivateUsers("backend", []string{"author", "u1", "u2", "u3"}); err != nil {
+					errs <- fmt.Errorf("bulk deactivation: %w", err)
+				}
+			}()
+			for i := prs - 1; i >= 0; i-- {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					<-start
+					// Ревьюера могла успеть снять деактивация, а кандидаты — закончиться.
+					_, _, err := rs.ReassignReviewer(fmt.Sprintf("pr-%d", i), reviewers[i][0])
+					if err != nil && errorCode(err) != "NOT_ASSIGNED" && errorCode(err) != "NO_CANDIDATE" {
+						errs <- fmt.Errorf("pr-%d: %w", i, err)
+					}
+				}(i)
+			}
+			close(start)
+			wg.Wait()
+			close(errs)
+			for err := range errs {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package service
+
+import (
+	"PR/apperr"
+	"errors"
+)
+
+// notFoundAs уточняет сообщение NOT_FOUND из репозитория под контекст вызова
+// (например, "author not found" вместо "user not found"), прочие ошибки не трогает.
+func notFoundAs(err error, message string) error {
+	if errors.Is(err, apperr.ErrNotFound) {
+		return apperr.NotFound(message)
+	}
+	return err
+}
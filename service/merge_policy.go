@@ -0,0 +1,30 @@
+package service
+
+import (
+	"PR/models"
+	"fmt"
+)
+
+// checkMergePolicy возвращает список невыполненных условий политики команды.
+// pr.Reviews должны быть заполнены.
+func (rs *ReviewService) checkMergePolicy(team *models.Team, pr *models.PullRequest) []string {
+	var unmet []string
+
+	approvals := 0
+	for _, review := range pr.Reviews {
+		switch review.State {
+		case models.ReviewApproved:
+			approvals++
+		case models.ReviewChangesRequested:
+			if team.BlockOnChangesRequested {
+				unmet = append(unmet, fmt.Sprintf("changes requested by %s", review.UserID))
+			}
+		}
+	}
+
+	if approvals < team.RequiredApprovals {
+		unmet = append(unmet, fmt.Sprintf("requires %d approvals, has %d", team.RequiredApprovals, approvals))
+	}
+
+	return unmet
+}
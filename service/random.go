@@ -0,0 +1,42 @@
+package service
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Random — источник случайности для стратегий выбора ревьюеров.
+// Реализации должны быть безопасны для одновременного вызова из разных горутин.
+type Random interface {
+	Intn(n int) int
+	Shuffle(n int, swap func(i, j int))
+}
+
+type lockedRandom struct {
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewRandom возвращает потокобезопасный источник с заданным seed:
+// при одинаковом seed последовательность выборов повторяется.
+func NewRandom(seed int64) Random {
+	return &lockedRandom{rng: rand.New(rand.NewSource(seed))}
+}
+
+// NewTimeSeededRandom возвращает потокобезопасный источник, инициализированный текущим временем.
+func NewTimeSeededRandom() Random {
+	return NewRandom(time.Now().UnixNano())
+}
+
+func (r *lockedRandom) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rng.Intn(n)
+}
+
+func (r *lockedRandom) Shuffle(n int, swap func(i, j int)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rng.Shuffle(n, swap)
+}
@@ -1,150 +1,633 @@
 package service
 
 import (
+	"PR/apperr"
+	"PR/metrics"
 	"PR/models"
 	"PR/repository"
 	"errors"
-	"math/rand"
+	"log"
+	"sort"
+	"strings"
 	"time"
-
-	"github.com/jackc/pgtype"
 )
 
 type ReviewService struct {
-	repo *repository.Repository
-	rng  *rand.Rand
+	repo       repository.Store
+	strategies map[string]AssignmentStrategy
+	actor      string
 }
 
-func NewReviewService(repo *repository.Repository) *ReviewService {
-	scr := rand.NewSource(time.Now().UnixNano())
+// NewReviewService создаёт сервис; random передаётся стратегиям выбора ревьюеров.
+// Если он не задан, используется источник, инициализированный текущим временем.
+func NewReviewService(repo repository.Store, random Random) *ReviewService {
+	if random == nil {
+		random = NewTimeSeededRandom()
+	}
 	return &ReviewService{
-		repo: repo,
-		rng:  rand.New(scr),
+		repo:       repo,
+		strategies: newStrategies(random),
 	}
 }
 
-func (rs *ReviewService) CreateTeam(team models.Team) error {
-	return rs.repo.CreateTeam(team)
+// CreateTeam создаёт команду с участниками. Участники, уже состоящие в другой команде,
+// переводятся в новую только при allowMove, иначе возвращается USER_IN_OTHER_TEAM.
+// Пользователи, ранее удалённые из команды, просто добавляются в новую.
+func (rs *ReviewService) CreateTeam(team *models.Team, allowMove bool) error {
+	if team.AssignmentStrategy == "" {
+		team.AssignmentStrategy = models.DefaultAssignmentStrategy
+	}
+	if team.MaxReviewers == 0 {
+		team.MaxReviewers = models.DefaultMaxReviewers
+	}
+	if err := rs.validateTeamSettings(team); err != nil {
+		return err
+	}
+
+	return rs.repo.Transaction(func(tx repository.Store) error {
+		moved := map[string]string{}
+		for _, member := range team.Members {
+			existing, err := tx.GetUser(member.UserId)
+			if errors.Is(err, apperr.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if existing.TeamName != "" && existing.TeamName != team.TeamName {
+				moved[member.UserId] = existing.TeamName
+			}
+		}
+
+		if len(moved) > 0 && !allowMove {
+			return apperr.Conflict("USER_IN_OTHER_TEAM", "some members already belong to another team; use /users/moveTeam or set allow_move_members").
+				WithDetails(sortedKeys(moved)...)
+		}
+
+		if err := tx.CreateTeam(*team); err != nil {
+			return err
+		}
+
+		if err := rs.audit(tx, models.AuditTeamCreated, models.AuditEntityTeam, team.TeamName, map[string]any{
+			"members":             userIDs(team.Members),
+			"assignment_strategy": team.AssignmentStrategy,
+		}); err != nil {
+			return err
+		}
+
+		for _, userID := range sortedKeys(moved) {
+			if err := rs.audit(tx, models.AuditUserMoved, models.AuditEntityUser, userID, map[string]any{
+				"from_team":             moved[userID],
+				"to_team":               team.TeamName,
+				"reassign_open_reviews": false,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (rs *ReviewService) GetTeam(teamName string) (*models.Team, error) {
 	return rs.repo.GetTeam(teamName)
 }
 
-func (rs *ReviewService) SetUserActive(UserId string, IsActive bool) (*models.User, error) {
-	return rs.repo.UpdateUserActive(UserId, IsActive)
+func (rs *ReviewService) UpdateTeamSettings(teamName string, update models.TeamSettingsUpdate) (*models.Team, error) {
+	var result *models.Team
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		team, err := tx.GetTeamForUpdate(teamName)
+		if err != nil {
+			return err
+		}
+
+		if update.AssignmentStrategy != nil {
+			team.AssignmentStrategy = *update.AssignmentStrategy
+		}
+		if update.MinReviewers != nil {
+			team.MinReviewers = *update.MinReviewers
+		}
+		if update.MaxReviewers != nil {
+			team.MaxReviewers = *update.MaxReviewers
+		}
+		if update.RequiredApprovals != nil {
+			team.RequiredApprovals = *update.RequiredApprovals
+		}
+		if update.BlockOnChangesRequested != nil {
+			team.BlockOnChangesRequested = *update.BlockOnChangesRequested
+		}
+		if err := rs.validateTeamSettings(team); err != nil {
+			return err
+		}
+
+		if err := tx.UpdateTeam(team); err != nil {
+			return err
+		}
+
+		if err := rs.audit(tx, models.AuditTeamSettingsUpdated, models.AuditEntityTeam, team.TeamName, map[string]any{
+			"assignment_strategy":        team.AssignmentStrategy,
+			"min_reviewers":              team.MinReviewers,
+			"max_reviewers":              team.MaxReviewers,
+			"required_approvals":         team.RequiredApprovals,
+			"block_on_changes_requested": team.BlockOnChangesRequested,
+		}); err != nil {
+			return err
+		}
+
+		result = team
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
 
-func (rs *ReviewService) CreatePR(prID, prName, authorID string) (*models.PullRequest, error) {
-	if existing, _ := rs.repo.GetPR(prID); existing != nil {
-		return nil, errors.New("PR уже существует")
+func (rs *ReviewService) validateTeamSettings(team *models.Team) error {
+	if _, ok := rs.strategies[team.AssignmentStrategy]; !ok {
+		return apperr.Validation("unknown assignment strategy")
+	}
+	if team.MinReviewers < 0 || team.MaxReviewers < 1 || team.MinReviewers > team.MaxReviewers {
+		return apperr.Validation("min_reviewers must be between 0 and max_reviewers, max_reviewers must be at least 1")
+	}
+	if team.RequiredApprovals < 0 {
+		return apperr.Validation("required_approvals must not be negative")
 	}
+	return nil
+}
+
+func (rs *ReviewService) SetUserActive(UserId string, IsActive bool) (*models.User, error) {
+	var result *models.User
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		user, err := tx.UpdateUserActive(UserId, IsActive)
+		if err != nil {
+			return err
+		}
+
+		action := models.AuditUserDeactivated
+		if IsActive {
+			action = models.AuditUserActivated
+		}
+		if err := rs.audit(tx, action, models.AuditEntityUser, user.UserId, nil); err != nil {
+			return err
+		}
 
-	author, err := rs.repo.GetUser(authorID)
+		result = user
+		return nil
+	})
 	if err != nil {
-		return nil, errors.New("Автор не найден")
+		return nil, err
 	}
 
-	teamMembers, err := rs.repo.GetActiveTeamMembers(author.TeamName)
+	return result, nil
+}
+
+func (rs *ReviewService) CreatePR(prID, prName, authorID string, isDraft bool) (*models.PullRequest, error) {
+	var result *models.PullRequest
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		if _, err := tx.GetPRStatus(prID); err == nil {
+			return apperr.AlreadyExists("PR_EXISTS", "PR id already exists")
+		} else if !errors.Is(err, apperr.ErrNotFound) {
+			return err
+		}
+
+		team, err := authorTeam(tx, authorID)
+		if err != nil {
+			return err
+		}
+
+		pr := &models.PullRequest{
+			PullRequestID:   prID,
+			PullRequestName: prName,
+			AuthorID:        authorID,
+			Status:          models.StatusOpen,
+			IsDraft:         isDraft,
+			CreatedAt:       time.Now(),
+		}
+		pr.SyncReviewers()
+
+		if err := rs.auditPR(tx, models.AuditPRCreated, pr, map[string]any{
+			"pull_request_name": prName,
+			"author_id":         authorID,
+			"is_draft":          isDraft,
+		}); err != nil {
+			return err
+		}
+
+		if !isDraft {
+			if err := rs.assignReviewers(tx, pr, team); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.CreatePR(*pr); err != nil {
+			return err
+		}
+
+		result = pr
+		return nil
+	})
 	if err != nil {
-		return nil, errors.New("Команда не найдена")
+		return nil, err
 	}
+	metrics.PRCreated()
+
+	return result, nil
+}
+
+// MarkReady снимает с PR признак черновика и назначает ревьюеров по тем же
+// правилам, что и при создании обычного PR.
+func (rs *ReviewService) MarkReady(prID string) (*models.PullRequest, error) {
+	var result *models.PullRequest
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		pr, err := tx.GetPRForUpdate(prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusMerged {
+			return apperr.Conflict("PR_MERGED", "PR is already merged")
+		}
+
+		if pr.Status == models.StatusClosed {
+			return apperr.Conflict("PR_CLOSED", "cannot mark closed PR as ready")
+		}
+
+		if pr.IsDraft {
+			team, err := authorTeam(tx, pr.AuthorID)
+			if err != nil {
+				return err
+			}
+
+			if err := rs.auditPR(tx, models.AuditPRReady, pr, nil); err != nil {
+				return err
+			}
+
+			if err := rs.assignReviewers(tx, pr, team); err != nil {
+				return err
+			}
+			pr.IsDraft = false
 
-	candidates := rs.FilterCandidates(teamMembers, authorID)
-	reviewers := rs.SelectReviewers(candidates, 2)
+			if err := tx.UpdatePR(pr); err != nil {
+				return err
+			}
+		}
 
-	reviewersArray := pgtype.TextArray{}
-	if err := reviewersArray.Set(reviewers); err != nil {
+		result = pr
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	pr := models.PullRequest{
-		PullRequestID:     prID,
-		PullRequestName:   prName,
-		AuthorID:          authorID,
-		Status:            models.StatusOpen,
-		AssignedReviewers: reviewersArray,
-		CreatedAt:         time.Now(),
-	}
+	return result, nil
+}
 
-	if err := rs.repo.CreatePR(pr); err != nil {
-		return nil, err
+// authorTeam возвращает команду автора PR. Если автора убрали из команды,
+// ревьюеров для его PR выбирать не из кого и политику слияния взять неоткуда:
+// такие операции отклоняются с AUTHOR_NOT_IN_TEAM, пока автора не вернут в команду.
+func authorTeam(store repository.Store, authorID string) (*models.Team, error) {
+	author, err := store.GetUser(authorID)
+	if err != nil {
+		return nil, notFoundAs(err, "author not found")
+	}
+	if author.TeamName == "" {
+		return nil, apperr.Conflict("AUTHOR_NOT_IN_TEAM", "PR author is not a member of any team; add them back with /team/addMembers")
 	}
 
-	return &pr, nil
+	team, err := store.GetTeam(author.TeamName)
+	if err != nil {
+		return nil, notFoundAs(err, "team not found")
+	}
+	return team, nil
 }
 
-func (rs *ReviewService) MergePR(prID string) (*models.PullRequest, error) {
-	pr, err := rs.repo.GetPR(prID)
+// assignReviewers выбирает ревьюеров из активных участников команды автора
+// и назначает их на pr. Сохранение pr остаётся за вызывающим.
+func (rs *ReviewService) assignReviewers(store repository.Store, pr *models.PullRequest, team *models.Team) error {
+	teamMembers, err := store.GetActiveTeamMembers(team.TeamName)
 	if err != nil {
-		return nil, errors.New("PR не найден")
+		return err
 	}
 
-	if pr.Status == models.StatusMerged {
-		return pr, nil
+	candidates := rs.FilterCandidates(teamMembers, pr.AuthorID)
+	reviewers, err := rs.SelectReviewers(store, team, candidates, team.MaxReviewers)
+	if err != nil {
+		return err
 	}
 
-	pr.Status = models.StatusMerged
-	now := time.Now()
-	pr.MergedAt = &now
+	if len(reviewers) < team.MinReviewers {
+		return apperr.NoCandidate("NOT_ENOUGH_REVIEWERS", "not enough active team members to meet team min_reviewers")
+	}
 
-	if err := rs.repo.UpdatePR(pr); err != nil {
-		return nil, errors.New("Не получилось обновить статус PR")
+	now := time.Now()
+	for _, reviewerID := range reviewers {
+		pr.AssignReviewer(reviewerID, now)
+		if err := rs.auditPR(store, models.AuditReviewerAssigned, pr, map[string]any{
+			"reviewer_id":    reviewerID,
+			"strategy":       rs.strategyFor(team).Name(),
+			"candidate_pool": len(candidates),
+		}); err != nil {
+			return err
+		}
 	}
 
-	return pr, nil
+	return nil
 }
 
-func (rs *ReviewService) ReassignReviewer(prID, oldUserID string) (*models.PullRequest, string, error) {
-	pr, err := rs.repo.GetPR(prID)
+func (rs *ReviewService) MergePR(prID string, override bool) (*models.PullRequest, error) {
+	var result *models.PullRequest
+	merged := false
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		pr, err := tx.GetPRForUpdate(prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusMerged {
+			result = pr
+			return nil
+		}
+
+		if !pr.Status.CanTransitionTo(models.StatusMerged) {
+			return apperr.Conflict("PR_CLOSED", "cannot merge closed PR")
+		}
+
+		if pr.IsDraft {
+			return apperr.Conflict("PR_DRAFT", "cannot merge draft PR")
+		}
+
+		team, err := authorTeam(tx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		unmet := rs.checkMergePolicy(team, pr)
+		if len(unmet) > 0 {
+			if !override {
+				return apperr.Conflict("POLICY_NOT_SATISFIED", "merge policy is not satisfied").WithDetails(unmet...)
+			}
+			log.Printf("PR %s слит в обход политики: %s", pr.PullRequestID, strings.Join(unmet, "; "))
+			pr.MergeOverride = true
+		}
+
+		pr.Status = models.StatusMerged
+		now := time.Now()
+		pr.MergedAt = &now
+
+		if err := tx.UpdatePR(pr); err != nil {
+			return err
+		}
+
+		details := map[string]any{"override": pr.MergeOverride}
+		if len(unmet) > 0 {
+			details["unmet_policy"] = unmet
+		}
+		if err := rs.auditPR(tx, models.AuditPRMerged, pr, details); err != nil {
+			return err
+		}
+
+		result = pr
+		merged = true
+		return nil
+	})
 	if err != nil {
-		return nil, "", errors.New("PR не найден")
+		return nil, err
 	}
-
-	if pr.Status == models.StatusMerged {
-		return nil, "", errors.New("Нельзя переназначать ревьюера на замердженном PR")
+	if merged {
+		metrics.PRMerged()
 	}
 
-	var currentReviewers []string
-	if err := pr.AssignedReviewers.AssignTo(&currentReviewers); err != nil {
-		return nil, "", errors.New("Ошибка при чтении списка ревьюеров")
-	}
+	return result, nil
+}
+
+func (rs *ReviewService) ClosePR(prID string) (*models.PullRequest, error) {
+	var result *models.PullRequest
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		pr, err := tx.GetPRForUpdate(prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status != models.StatusClosed {
+			if !pr.Status.CanTransitionTo(models.StatusClosed) {
+				return apperr.Conflict("PR_MERGED", "cannot close merged PR")
+			}
 
-	if !rs.Contains(currentReviewers, oldUserID) {
-		return nil, "", errors.New("Данный ревьюер и не был назначен на данный PR")
+			pr.Status = models.StatusClosed
+			now := time.Now()
+			pr.ClosedAt = &now
+
+			if err := tx.UpdatePR(pr); err != nil {
+				return err
+			}
+
+			if err := rs.auditPR(tx, models.AuditPRClosed, pr, nil); err != nil {
+				return err
+			}
+		}
+
+		result = pr
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	oldUser, err := rs.repo.GetUser(oldUserID)
+	return result, nil
+}
+
+// ReopenPR возвращает закрытый PR в OPEN. Ревьюеры, ставшие неактивными,
+// пока PR был закрыт, заменяются так же, как при массовой деактивации.
+func (rs *ReviewService) ReopenPR(prID string) (*models.PullRequest, []models.ReviewerReplacement, []models.RemovedReviewer, error) {
+	var result *models.PullRequest
+	var replaced []models.ReviewerReplacement
+	var removed []models.RemovedReviewer
+
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		pr, err := tx.GetPRForUpdate(prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status != models.StatusOpen {
+			if !pr.Status.CanTransitionTo(models.StatusOpen) {
+				return apperr.Conflict("PR_MERGED", "cannot reopen merged PR")
+			}
+
+			team, err := authorTeam(tx, pr.AuthorID)
+			if err != nil {
+				return err
+			}
+
+			activeMembers, err := tx.GetActiveTeamMembers(team.TeamName)
+			if err != nil {
+				return err
+			}
+
+			var inactive []string
+			for _, reviewerID := range pr.AssignedReviewers {
+				reviewer, err := tx.GetUser(reviewerID)
+				if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+					return err
+				}
+				if err != nil || !reviewer.IsActive {
+					inactive = append(inactive, reviewerID)
+				}
+			}
+
+			if err := rs.auditPR(tx, models.AuditPRReopened, pr, nil); err != nil {
+				return err
+			}
+
+			replaced, removed, err = rs.replaceLeavingReviewers(tx, team, activeMembers, pr, inactive, "reopen")
+			if err != nil {
+				return err
+			}
+
+			pr.Status = models.StatusOpen
+			pr.ClosedAt = nil
+
+			if err := tx.UpdatePR(pr); err != nil {
+				return err
+			}
+		}
+
+		result = pr
+		return nil
+	})
 	if err != nil {
-		return nil, "", errors.New("Пользователь не найден")
+		return nil, nil, nil, err
 	}
+	metrics.ReviewersReassigned("reopen", len(replaced))
 
-	candidates, err := rs.repo.GetActiveTeamMembers(oldUser.TeamName)
-	if err != nil || len(candidates) <= 2 {
-		return nil, "", errors.New("Нет доступных кандидатов для замены")
+	if replaced == nil {
+		replaced = []models.ReviewerReplacement{}
 	}
+	if removed == nil {
+		removed = []models.RemovedReviewer{}
+	}
+	return result, replaced, removed, nil
+}
 
-	availableCandidates := rs.FilterReassignmentCandidates(candidates, currentReviewers, pr.AuthorID, oldUserID)
+func (rs *ReviewService) ReassignReviewer(prID, oldUserID string) (*models.PullRequest, string, error) {
+	var result *models.PullRequest
+	var newReviewer string
 
-	if len(availableCandidates) == 0 {
-		return nil, "", errors.New("Нет доступных кандидатов для замены")
-	}
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		pr, err := tx.GetPRForUpdate(prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusMerged {
+			return apperr.Conflict("PR_MERGED", "cannot reassign on merged PR")
+		}
+
+		if pr.Status == models.StatusClosed {
+			return apperr.Conflict("PR_CLOSED", "cannot reassign on closed PR")
+		}
+
+		if !pr.HasReviewer(oldUserID) {
+			return apperr.Conflict("NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		}
+
+		// Замену ищем в команде автора PR: ревьюер мог с тех пор перейти в другую команду.
+		team, err := authorTeam(tx, pr.AuthorID)
+		if err != nil {
+			return err
+		}
+
+		candidates, err := tx.GetActiveTeamMembers(team.TeamName)
+		if err != nil {
+			return err
+		}
 
-	newReviewer := availableCandidates[rs.rng.Intn(len(availableCandidates))].UserId
-	newReviewers := rs.ReplaceReviewer(currentReviewers, oldUserID, newReviewer)
+		availableCandidates := rs.FilterReassignmentCandidates(candidates, pr.AssignedReviewers, pr.AuthorID, oldUserID)
 
-	newReviewersArray := pgtype.TextArray{}
-	if err := newReviewersArray.Set(newReviewers); err != nil {
+		if len(availableCandidates) == 0 {
+			return apperr.NoCandidate("NO_CANDIDATE", "no active replacement candidate in team")
+		}
+
+		selected, err := rs.SelectReviewers(tx, team, availableCandidates, 1)
+		if err != nil {
+			return err
+		}
+
+		newReviewer = selected[0]
+		if err := pr.ReplaceReviewer(oldUserID, newReviewer, time.Now()); err != nil {
+			return err
+		}
+
+		if err := tx.UpdatePR(pr); err != nil {
+			return err
+		}
+
+		if err := rs.auditPR(tx, models.AuditReviewerReplaced, pr, map[string]any{
+			"old_reviewer_id": oldUserID,
+			"new_reviewer_id": newReviewer,
+			"strategy":        rs.strategyFor(team).Name(),
+			"candidate_pool":  len(availableCandidates),
+			"reason":          "reassign",
+		}); err != nil {
+			return err
+		}
+
+		result = pr
+		return nil
+	})
+	if err != nil {
 		return nil, "", err
 	}
+	metrics.ReviewersReassigned("reassign", 1)
 
-	pr.AssignedReviewers = newReviewersArray
+	return result, newReviewer, nil
+}
 
-	if err := rs.repo.UpdatePR(pr); err != nil {
-		return nil, "", err
+func (rs *ReviewService) SubmitReview(prID, reviewerID string, state models.ReviewState, comment string) (*models.PullRequest, error) {
+	if !state.IsDecision() {
+		return nil, apperr.Validation("decision must be one of APPROVED, CHANGES_REQUESTED, COMMENTED")
+	}
+
+	var result *models.PullRequest
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		pr, err := tx.GetPRForUpdate(prID)
+		if err != nil {
+			return err
+		}
+
+		if pr.Status == models.StatusMerged {
+			return apperr.Conflict("PR_MERGED", "cannot review merged PR")
+		}
+
+		if pr.Status == models.StatusClosed {
+			return apperr.Conflict("PR_CLOSED", "cannot review closed PR")
+		}
+
+		if err := pr.SetReview(reviewerID, state, comment, time.Now()); err != nil {
+			return apperr.Conflict("NOT_ASSIGNED", "reviewer is not assigned to this PR")
+		}
+
+		if err := tx.UpdatePR(pr); err != nil {
+			return err
+		}
+
+		if err := rs.auditPR(tx, models.AuditReviewSubmitted, pr, map[string]any{
+			"reviewer_id": reviewerID,
+			"decision":    state,
+			"comment":     comment,
+		}); err != nil {
+			return err
+		}
+
+		result = pr
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return pr, newReviewer, nil
+	return result, nil
 }
 
 func (rs *ReviewService) GetUserReviews(userID string) ([]models.PullRequestShort, error) {
@@ -155,41 +638,219 @@ func (rs *ReviewService) GetUserReviews(userID string) ([]models.PullRequestShor
 
 	var result []models.PullRequestShort
 	for _, pr := range prs {
+		state := models.ReviewPending
+		for _, review := range pr.Reviews {
+			if review.UserID == userID {
+				state = review.State
+			}
+		}
+
 		result = append(result, models.PullRequestShort{
 			PullRequestID:   pr.PullRequestID,
 			PullRequestName: pr.PullRequestName,
 			AuthorID:        pr.AuthorID,
 			Status:          pr.Status,
+			ReviewState:     state,
 		})
 	}
 
 	return result, nil
 }
 
-func (rs *ReviewService) BulkDeactivateUsers(teamName string, excludeUserIDs []string) (int64, error) {
-	return rs.repo.BulkDeactivateUsers(teamName, excludeUserIDs)
-}
+func (rs *ReviewService) BulkDeactivateUsers(teamName string, excludeUserIDs []string) (*models.BulkDeactivationReport, error) {
+	start := time.Now()
+	report := &models.BulkDeactivationReport{
+		TeamName:                  teamName,
+		DeactivatedUserIDs:        []string{},
+		ChangedPullRequests:       []string{},
+		Replacements:              []models.ReviewerReplacement{},
+		RemovedWithoutReplacement: []models.RemovedReviewer{},
+	}
 
-func (rs *ReviewService) GetUserReviewStats(userID string) (map[string]interface{}, error) {
-	prs, err := rs.repo.GetPRsByReviewer(userID)
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		members, err := tx.GetActiveTeamMembers(teamName)
+		if err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			if !rs.Contains(excludeUserIDs, member.UserId) {
+				report.DeactivatedUserIDs = append(report.DeactivatedUserIDs, member.UserId)
+			}
+		}
+
+		affected, err := tx.BulkDeactivateUsers(teamName, excludeUserIDs)
+		if err != nil {
+			return err
+		}
+		report.DeactivatedUsers = affected
+
+		for _, userID := range report.DeactivatedUserIDs {
+			if err := rs.audit(tx, models.AuditUserDeactivated, models.AuditEntityUser, userID, map[string]any{
+				"reason": "bulk_deactivation",
+			}); err != nil {
+				return err
+			}
+		}
+
+		prs, err := tx.GetOpenPRsByReviewers(report.DeactivatedUserIDs)
+		if err != nil {
+			return err
+		}
+
+		locked, err := lockOpenPRs(tx, prs)
+		if err != nil {
+			return err
+		}
+
+		pools := map[string][]models.User{}
+		for _, pr := range locked {
+			team, activeMembers, err := rs.replacementPool(tx, pr, report.DeactivatedUserIDs, pools)
+			if err != nil {
+				return err
+			}
+
+			replaced, removed, err := rs.replaceLeavingReviewers(tx, team, activeMembers, pr, report.DeactivatedUserIDs, "deactivation")
+			if err != nil {
+				return err
+			}
+			if len(replaced) == 0 && len(removed) == 0 {
+				continue
+			}
+
+			if err := tx.UpdatePR(pr); err != nil {
+				return err
+			}
+			report.ChangedPullRequests = append(report.ChangedPullRequests, pr.PullRequestID)
+			report.Replacements = append(report.Replacements, replaced...)
+			report.RemovedWithoutReplacement = append(report.RemovedWithoutReplacement, removed...)
+		}
+
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	metrics.ReviewersReassigned("deactivation", len(report.Replacements))
 
-	total := len(prs)
-	open := 0
-	for _, pr := range prs {
+	report.DurationMs = time.Since(start).Milliseconds()
+	return report, nil
+}
+
+// lockOpenPRs блокирует строки prs в порядке pull_request_id и возвращает те из них,
+// что всё ещё открыты. Операции над несколькими PR блокируют их все до выбора первой
+// замены: стратегия round_robin блокирует строку команды, и если взять её между
+// блокировками PR, то параллельный ReassignReviewer, держащий следующий PR и ждущий
+// команду, образует взаимную блокировку. Везде порядок один: сначала PR, потом команда.
+func lockOpenPRs(store repository.Store, prs []models.PullRequest) ([]*models.PullRequest, error) {
+	ids := prIDs(prs)
+	sort.Strings(ids)
+
+	var locked []*models.PullRequest
+	for _, id := range ids {
+		pr, err := store.GetPRForUpdate(id)
+		if err != nil {
+			return nil, err
+		}
 		if pr.Status == models.StatusOpen {
-			open++
+			locked = append(locked, pr)
 		}
 	}
+	return locked, nil
+}
+
+// replacementPool возвращает команду автора pr и её активных участников, кроме leaving.
+// Замену ревьюеру ищут в команде автора PR, а не в команде уходящего ревьюера: после
+// перевода между командами это разные команды. pools кэширует участников по командам
+// на время одной операции.
+func (rs *ReviewService) replacementPool(store repository.Store, pr *models.PullRequest, leaving []string, pools map[string][]models.User) (*models.Team, []models.User, error) {
+	team, err := authorTeam(store, pr.AuthorID)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	return map[string]interface{}{
-		"user_id":           userID,
-		"total_reviews":     total,
-		"open_reviews":      open,
-		"completed_reviews": total - open,
-	}, nil
+	pool, ok := pools[team.TeamName]
+	if !ok {
+		members, err := store.GetActiveTeamMembers(team.TeamName)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, member := range members {
+			if !rs.Contains(leaving, member.UserId) {
+				pool = append(pool, member)
+			}
+		}
+		pools[team.TeamName] = pool
+	}
+	return team, pool, nil
+}
+
+// replaceLeavingReviewers заменяет каждого ревьюера из leaving, назначенного на pr,
+// подходящим активным участником команды, а если замены нет — просто снимает его.
+// Изменения вносятся в pr, сохранение остаётся за вызывающим; reason попадает в журнал аудита.
+func (rs *ReviewService) replaceLeavingReviewers(store repository.Store, team *models.Team, activeMembers []models.User, pr *models.PullRequest, leaving []string, reason string) ([]models.ReviewerReplacement, []models.RemovedReviewer, error) {
+	now := time.Now()
+
+	var replaced []models.ReviewerReplacement
+	var removed []models.RemovedReviewer
+	for _, oldUserID := range leaving {
+		if !pr.HasReviewer(oldUserID) {
+			continue
+		}
+
+		available := rs.FilterReassignmentCandidates(activeMembers, pr.AssignedReviewers, pr.AuthorID, oldUserID)
+		if len(available) == 0 {
+			if err := pr.RemoveReviewer(oldUserID, now); err != nil {
+				return nil, nil, err
+			}
+			if err := rs.auditPR(store, models.AuditReviewerRemoved, pr, map[string]any{
+				"reviewer_id": oldUserID,
+				"reason":      reason,
+			}); err != nil {
+				return nil, nil, err
+			}
+			removed = append(removed, models.RemovedReviewer{
+				PullRequestID: pr.PullRequestID,
+				UserID:        oldUserID,
+			})
+			continue
+		}
+
+		selected, err := rs.SelectReviewers(store, team, available, 1)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		newReviewer := selected[0]
+		if err := pr.ReplaceReviewer(oldUserID, newReviewer, now); err != nil {
+			return nil, nil, err
+		}
+		if err := rs.auditPR(store, models.AuditReviewerReplaced, pr, map[string]any{
+			"old_reviewer_id": oldUserID,
+			"new_reviewer_id": newReviewer,
+			"strategy":        rs.strategyFor(team).Name(),
+			"candidate_pool":  len(available),
+			"reason":          reason,
+		}); err != nil {
+			return nil, nil, err
+		}
+		replaced = append(replaced, models.ReviewerReplacement{
+			PullRequestID: pr.PullRequestID,
+			OldUserID:     oldUserID,
+			NewUserID:     newReviewer,
+		})
+	}
+
+	return replaced, removed, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func (rs *ReviewService) FilterCandidates(candidates []models.User, authorID string) []models.User {
@@ -202,24 +863,19 @@ func (rs *ReviewService) FilterCandidates(candidates []models.User, authorID str
 	return result
 }
 
-func (rs *ReviewService) SelectReviewers(candidates []models.User, max int) []string {
+func (rs *ReviewService) SelectReviewers(store repository.Store, team *models.Team, candidates []models.User, max int) ([]string, error) {
 	if len(candidates) == 0 {
-		return []string{}
+		return []string{}, nil
 	}
 
-	shuffled := make([]models.User, len(candidates))
-	copy(shuffled, candidates)
-	rs.rng.Shuffle(len(shuffled), func(i, j int) {
-		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
-	})
+	return rs.strategyFor(team).Select(store, team.TeamName, candidates, max)
+}
 
-	count := min(len(shuffled), max)
-	reviewers := make([]string, count)
-	for i := 0; i < count; i++ {
-		reviewers[i] = shuffled[i].UserId
+func (rs *ReviewService) strategyFor(team *models.Team) AssignmentStrategy {
+	if strategy, ok := rs.strategies[team.AssignmentStrategy]; ok {
+		return strategy
 	}
-
-	return reviewers
+	return rs.strategies[models.DefaultAssignmentStrategy]
 }
 
 func (rs *ReviewService) FilterReassignmentCandidates(candidates []models.User, currentReviewers []string, authorID, oldUserID string) []models.User {
@@ -242,15 +898,3 @@ func (rs *ReviewService) Contains(slice []string, item string) bool {
 	}
 	return false
 }
-
-func (rs *ReviewService) ReplaceReviewer(reviewers []string, old, new string) []string {
-	result := make([]string, len(reviewers))
-	for i, reviewer := range reviewers {
-		if reviewer != old {
-			result[i] = reviewer
-		} else {
-			result[i] = new
-		}
-	}
-	return result
-}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"PR/apperr"
+	"PR/models"
+	"math"
+	"sort"
+	"time"
+)
+
+// GetUserReviewStats считает назначения пользователя с assigned_at в интервале [from, to).
+// Если задан status, учитываются только PR в этом статусе.
+func (rs *ReviewService) GetUserReviewStats(userID string, from, to *time.Time, status models.PRStatus) (*models.UserReviewStats, error) {
+	switch status {
+	case "", models.StatusOpen, models.StatusMerged, models.StatusClosed:
+	default:
+		return nil, apperr.Validation("status must be one of OPEN, MERGED, CLOSED")
+	}
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, apperr.Validation("from must be before to")
+	}
+
+	prs, err := rs.repo.GetPRsEverAssigned(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.UserReviewStats{UserID: userID, From: from, To: to, Status: status}
+	for _, pr := range prs {
+		if status != "" && pr.Status != status {
+			continue
+		}
+
+		for _, assignment := range pr.Reviewers {
+			if assignment.UserID != userID || !inRange(assignment.AssignedAt, from, to) {
+				continue
+			}
+
+			stats.TotalReviews++
+			switch {
+			case !assignment.IsActive():
+				stats.ReassignedAway++
+			case pr.Status == models.StatusOpen:
+				stats.OpenReviews++
+			case pr.Status == models.StatusMerged:
+				stats.MergedReviews++
+			case pr.Status == models.StatusClosed:
+				stats.ClosedReviews++
+			}
+		}
+	}
+	stats.CompletedReviews = stats.MergedReviews + stats.ClosedReviews
+
+	return stats, nil
+}
+
+func (rs *ReviewService) GetTeamReviewStats(teamName string) (*models.TeamReviewStats, error) {
+	team, err := rs.repo.GetTeam(teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.TeamReviewStats{
+		TeamName: team.TeamName,
+		Members:  make([]models.MemberReviewStats, 0, len(team.Members)),
+	}
+
+	counts, err := rs.repo.CountReviews(userIDs(team.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	// Справедливость оценивается по текущей нагрузке: накопленные за всё время ревью
+	// не выравниваются и скрывали бы перекос у новых участников.
+	var activeLoads []int
+	for _, member := range team.Members {
+		count := counts[member.UserId]
+		stats.Members = append(stats.Members, models.MemberReviewStats{
+			UserID:           member.UserId,
+			Username:         member.UserName,
+			IsActive:         member.IsActive,
+			OpenReviews:      count.Open,
+			CompletedReviews: count.Completed,
+			TotalReviews:     count.Open + count.Completed,
+		})
+		if member.IsActive {
+			activeLoads = append(activeLoads, count.Open)
+		}
+	}
+
+	prs, err := rs.repo.GetPRsByAuthors(userIDs(team.Members))
+	if err != nil {
+		return nil, err
+	}
+
+	reviewed, assignments := 0, 0
+	for _, pr := range prs {
+		stats.TotalPullRequests++
+		if pr.Status == models.StatusOpen {
+			stats.OpenPullRequests++
+		}
+		// Черновики ещё не получали ревьюеров и не должны занижать среднее.
+		if !pr.IsDraft {
+			reviewed++
+			assignments += len(pr.AssignedReviewers)
+		}
+	}
+	if reviewed > 0 {
+		stats.AvgReviewersPerPR = round2(float64(assignments) / float64(reviewed))
+	}
+
+	stats.Fairness = reviewFairness(activeLoads)
+	return stats, nil
+}
+
+// reviewFairness считает коэффициент Джини нагрузки (0 — поровну, ближе к 1 — всё
+// на одном человеке) и отношение максимальной нагрузки к минимальной.
+func reviewFairness(loads []int) models.ReviewFairness {
+	var fairness models.ReviewFairness
+	if len(loads) == 0 {
+		return fairness
+	}
+
+	fairness.MaxReviews, fairness.MinReviews = loads[0], loads[0]
+	total, diffs := 0, 0
+	for _, a := range loads {
+		total += a
+		fairness.MaxReviews = max(fairness.MaxReviews, a)
+		fairness.MinReviews = min(fairness.MinReviews, a)
+		for _, b := range loads {
+			diffs += abs(a - b)
+		}
+	}
+
+	if total > 0 {
+		n := float64(len(loads))
+		fairness.Gini = round2(float64(diffs) / (2 * n * float64(total)))
+	}
+	if fairness.MinReviews > 0 {
+		ratio := round2(float64(fairness.MaxReviews) / float64(fairness.MinReviews))
+		fairness.MaxMinRatio = &ratio
+	}
+	return fairness
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+// GetLatencyStats считает время до слияния (от создания PR до merged_at) по командам
+// и авторам и время до первого решения ревьюера (от назначения), а также их понедельную
+// динамику. Учитываются слияния и первые решения в интервале [from, to). Если задан
+// teamName, учитываются только PR авторов этой команды и ревьюеры из неё.
+func (rs *ReviewService) GetLatencyStats(teamName string, from, to *time.Time) (*models.LatencyStats, error) {
+	if from != nil && to != nil && !from.Before(*to) {
+		return nil, apperr.Validation("from must be before to")
+	}
+
+	if teamName != "" {
+		if _, err := rs.repo.GetTeam(teamName); err != nil {
+			return nil, err
+		}
+	}
+
+	merged, err := rs.repo.GetMergeTimings(teamName, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []time.Duration
+	byTeam := map[string][]time.Duration{}
+	byAuthor := map[string][]time.Duration{}
+	mergeWeeks := map[string][]time.Duration{}
+	for _, pr := range merged {
+		d := pr.MergedAt.Sub(pr.CreatedAt)
+		all = append(all, d)
+		byTeam[pr.TeamName] = append(byTeam[pr.TeamName], d)
+		byAuthor[pr.AuthorID] = append(byAuthor[pr.AuthorID], d)
+		week := weekStart(pr.MergedAt)
+		mergeWeeks[week] = append(mergeWeeks[week], d)
+	}
+
+	decisions, err := rs.repo.GetDecisionTimings(teamName, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byReviewer := map[string][]time.Duration{}
+	reviewWeeks := map[string][]time.Duration{}
+	for _, decision := range decisions {
+		d := decision.FirstDecidedAt.Sub(decision.AssignedAt)
+		byReviewer[decision.UserID] = append(byReviewer[decision.UserID], d)
+		week := weekStart(decision.FirstDecidedAt)
+		reviewWeeks[week] = append(reviewWeeks[week], d)
+	}
+
+	stats := &models.LatencyStats{
+		From:                  from,
+		To:                    to,
+		TeamName:              teamName,
+		TimeToMerge:           durationStats(all),
+		TimeToMergeByTeam:     []models.TeamDurationStats{},
+		TimeToMergeByAuthor:   []models.AuthorDurationStats{},
+		FirstReviewByReviewer: []models.ReviewerDurationStats{},
+		Weekly:                []models.WeeklyLatencyStats{},
+	}
+	for _, team := range sortedKeys(byTeam) {
+		stats.TimeToMergeByTeam = append(stats.TimeToMergeByTeam, models.TeamDurationStats{
+			TeamName: team, DurationStats: durationStats(byTeam[team]),
+		})
+	}
+	for _, author := range sortedKeys(byAuthor) {
+		stats.TimeToMergeByAuthor = append(stats.TimeToMergeByAuthor, models.AuthorDurationStats{
+			AuthorID: author, DurationStats: durationStats(byAuthor[author]),
+		})
+	}
+	for _, reviewer := range sortedKeys(byReviewer) {
+		stats.FirstReviewByReviewer = append(stats.FirstReviewByReviewer, models.ReviewerDurationStats{
+			ReviewerID: reviewer, DurationStats: durationStats(byReviewer[reviewer]),
+		})
+	}
+
+	weeks := map[string][]time.Duration{}
+	for week := range mergeWeeks {
+		weeks[week] = nil
+	}
+	for week := range reviewWeeks {
+		weeks[week] = nil
+	}
+	for _, week := range sortedKeys(weeks) {
+		stats.Weekly = append(stats.Weekly, models.WeeklyLatencyStats{
+			WeekStart:   week,
+			TimeToMerge: durationStats(mergeWeeks[week]),
+			FirstReview: durationStats(reviewWeeks[week]),
+		})
+	}
+
+	return stats, nil
+}
+
+func inRange(t time.Time, from, to *time.Time) bool {
+	return (from == nil || !t.Before(*from)) && (to == nil || t.Before(*to))
+}
+
+func durationStats(durations []time.Duration) models.DurationStats {
+	if len(durations) == 0 {
+		return models.DurationStats{}
+	}
+
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	return models.DurationStats{
+		Count:       len(sorted),
+		MedianHours: round2(percentile(sorted, 0.5).Hours()),
+		P90Hours:    round2(percentile(sorted, 0.9).Hours()),
+	}
+}
+
+// percentile — линейная интерполяция между соседними значениями отсортированной выборки.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	pos := p * float64(len(sorted)-1)
+	lower := int(pos)
+	if lower+1 >= len(sorted) {
+		return sorted[lower]
+	}
+	frac := pos - float64(lower)
+	return sorted[lower] + time.Duration(frac*float64(sorted[lower+1]-sorted[lower]))
+}
+
+// weekStart возвращает понедельник недели t (UTC) в формате YYYY-MM-DD.
+func weekStart(t time.Time) string {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"PR/models"
+	"testing"
+	"time"
+)
+
+func TestDurationStats(t *testing.T) {
+	h := time.Hour
+	tests := []struct {
+		name      string
+		durations []time.Duration
+		want      models.DurationStats
+	}{
+		{"empty", nil, models.DurationStats{}},
+		{"single", []time.Duration{90 * time.Minute}, models.DurationStats{Count: 1, MedianHours: 1.5, P90Hours: 1.5}},
+		// p90 между двумя значениями: 1 + 0.9*(3-1).
+		{"two unsorted", []time.Duration{3 * h, h}, models.DurationStats{Count: 2, MedianHours: 2, P90Hours: 2.8}},
+		// Медиана — среднее двух средних, p90 — 3 + 0.7*(10-3).
+		{"even count", []time.Duration{10 * h, h, 3 * h, 2 * h}, models.DurationStats{Count: 4, MedianHours: 2.5, P90Hours: 7.9}},
+		{"odd count", []time.Duration{5 * h, h, 4 * h, 2 * h, 3 * h}, models.DurationStats{Count: 5, MedianHours: 3, P90Hours: 4.6}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := durationStats(tt.durations); got != tt.want {
+				t.Fatalf("durationStats(%v) = %+v, want %+v", tt.durations, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDurationStatsKeepsInputOrder(t *testing.T) {
+	durations := []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour}
+	durationStats(durations)
+	if durations[0] != 3*time.Hour || durations[1] != time.Hour || durations[2] != 2*time.Hour {
+		t.Fatalf("input was reordered: %v", durations)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	msk := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		name string
+		t    time.Time
+		want string
+	}{
+		{"monday midnight", time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), "2026-10-12"},
+		{"sunday end of day", time.Date(2026, 10, 18, 23, 59, 59, 0, time.UTC), "2026-10-12"},
+		{"next monday", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), "2026-10-19"},
+		{"week across new year", time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC), "2025-12-29"},
+		// 01:00 понедельника по Москве — ещё воскресенье по UTC.
+		{"converted to utc", time.Date(2026, 10, 19, 1, 0, 0, 0, msk), "2026-10-12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weekStart(tt.t); got != tt.want {
+				t.Fatalf("weekStart(%v) = %s, want %s", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReviewFairness(t *testing.T) {
+	ratio := func(x float64) *float64 { return &x }
+	tests := []struct {
+		name  string
+		loads []int
+		want  models.ReviewFairness
+	}{
+		{"no members", nil, models.ReviewFairness{}},
+		{"single member", []int{3}, models.ReviewFairness{MaxReviews: 3, MinReviews: 3, MaxMinRatio: ratio(1)}},
+		{"equal loads", []int{2, 2, 2}, models.ReviewFairness{MaxReviews: 2, MinReviews: 2, MaxMinRatio: ratio(1)}},
+		{"nobody has reviews", []int{0, 0}, models.ReviewFairness{}},
+		// Всё на одном из двух: 8 / (2*2*4).
+		{"all on one", []int{0, 4}, models.ReviewFairness{Gini: 0.5, MaxReviews: 4}},
+		{"uneven pair", []int{1, 3}, models.ReviewFairness{Gini: 0.25, MaxReviews: 3, MinReviews: 1, MaxMinRatio: ratio(3)}},
+		// Сумма попарных разностей 8 / (2*3*6).
+		{"three members", []int{3, 1, 2}, models.ReviewFairness{Gini: 0.22, MaxReviews: 3, MinReviews: 1, MaxMinRatio: ratio(3)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := reviewFairness(tt.loads)
+			if got.Gini != tt.want.Gini || got.MaxReviews != tt.want.MaxReviews || got.MinReviews != tt.want.MinReviews {
+				t.Fatalf("reviewFairness(%v) = %+v, want %+v", tt.loads, got, tt.want)
+			}
+			switch {
+			case got.MaxMinRatio == nil && tt.want.MaxMinRatio == nil:
+			case got.MaxMinRatio == nil || tt.want.MaxMinRatio == nil || *got.MaxMinRatio != *tt.want.MaxMinRatio:
+				t.Fatalf("reviewFairness(%v).MaxMinRatio = %v, want %v", tt.loads, got.MaxMinRatio, tt.want.MaxMinRatio)
+			}
+		})
+	}
+}
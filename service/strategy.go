@@ -0,0 +1,161 @@
+package service
+
+import (
+	"PR/models"
+	"PR/repository"
+	"sort"
+)
+
+type AssignmentStrategy interface {
+	Name() string
+	Select(store repository.Store, teamName string, candidates []models.User, count int) ([]string, error)
+}
+
+func newStrategies(rng Random) map[string]AssignmentStrategy {
+	strategies := []AssignmentStrategy{
+		&randomStrategy{rng: rng},
+		&roundRobinStrategy{},
+		&leastLoadedStrategy{rng: rng},
+		&weightedStrategy{rng: rng},
+	}
+
+	result := make(map[string]AssignmentStrategy, len(strategies))
+	for _, strategy := range strategies {
+		result[strategy.Name()] = strategy
+	}
+	return result
+}
+
+type randomStrategy struct {
+	rng Random
+}
+
+func (s *randomStrategy) Name() string {
+	return models.StrategyRandom
+}
+
+func (s *randomStrategy) Select(_ repository.Store, _ string, candidates []models.User, count int) ([]string, error) {
+	ids := userIDs(candidates)
+	s.rng.Shuffle(len(ids), func(i, j int) {
+		ids[i], ids[j] = ids[j], ids[i]
+	})
+	return ids[:min(len(ids), count)], nil
+}
+
+// roundRobinStrategy обходит кандидатов команды по кругу в порядке user_id,
+// продолжая с того, кто идёт после последнего назначенного в команде ревьюера.
+// Позиция берётся из сохранённых назначений, поэтому откатившаяся транзакция
+// её не сдвигает, а перезапуск сервиса не сбрасывает.
+type roundRobinStrategy struct{}
+
+func (s *roundRobinStrategy) Name() string {
+	return models.StrategyRoundRobin
+}
+
+func (s *roundRobinStrategy) Select(store repository.Store, teamName string, candidates []models.User, count int) ([]string, error) {
+	ids := userIDs(candidates)
+	if len(ids) == 0 {
+		return ids, nil
+	}
+	sort.Strings(ids)
+
+	// Блокировка строки команды выстраивает параллельные выборы в одной команде
+	// в очередь до конца транзакции, иначе они продолжили бы с одной и той же позиции.
+	// Вызывающие уже держат строки нужных PR и новых PR после этого не блокируют (см. lockOpenPRs).
+	if _, err := store.GetTeamForUpdate(teamName); err != nil {
+		return nil, err
+	}
+	last, err := store.GetLastAssignedReviewer(teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	start := sort.SearchStrings(ids, last)
+	if start < len(ids) && ids[start] == last {
+		start++
+	}
+
+	count = min(len(ids), count)
+	selected := make([]string, count)
+	for i := 0; i < count; i++ {
+		selected[i] = ids[(start+i)%len(ids)]
+	}
+	return selected, nil
+}
+
+// leastLoadedStrategy отдаёт предпочтение кандидатам с наименьшим числом открытых ревью,
+// при равной нагрузке выбор случайный.
+type leastLoadedStrategy struct {
+	rng Random
+}
+
+func (s *leastLoadedStrategy) Name() string {
+	return models.StrategyLeastLoaded
+}
+
+func (s *leastLoadedStrategy) Select(store repository.Store, _ string, candidates []models.User, count int) ([]string, error) {
+	ids := userIDs(candidates)
+
+	loads, err := store.CountOpenReviews(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	s.rng.Shuffle(len(ids), func(i, j int) {
+		ids[i], ids[j] = ids[j], ids[i]
+	})
+	sort.SliceStable(ids, func(i, j int) bool {
+		return loads[ids[i]] < loads[ids[j]]
+	})
+
+	return ids[:min(len(ids), count)], nil
+}
+
+// weightedStrategy выбирает без повторов с вероятностью, пропорциональной review_weight.
+type weightedStrategy struct {
+	rng Random
+}
+
+func (s *weightedStrategy) Name() string {
+	return models.StrategyWeighted
+}
+
+func (s *weightedStrategy) Select(_ repository.Store, _ string, candidates []models.User, count int) ([]string, error) {
+	pool := make([]models.User, len(candidates))
+	copy(pool, candidates)
+
+	count = min(len(pool), count)
+	selected := make([]string, 0, count)
+	for len(selected) < count {
+		total := 0
+		for _, user := range pool {
+			total += userWeight(user)
+		}
+
+		pick := s.rng.Intn(total)
+		for i, user := range pool {
+			pick -= userWeight(user)
+			if pick < 0 {
+				selected = append(selected, user.UserId)
+				pool = append(pool[:i], pool[i+1:]...)
+				break
+			}
+		}
+	}
+	return selected, nil
+}
+
+func userWeight(user models.User) int {
+	if user.ReviewWeight <= 0 {
+		return 1
+	}
+	return user.ReviewWeight
+}
+
+func userIDs(users []models.User) []string {
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.UserId
+	}
+	return ids
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"PR/apperr"
+	"PR/metrics"
+	"PR/models"
+	"PR/repository"
+	"errors"
+)
+
+func (rs *ReviewService) AddTeamMembers(teamName string, members []models.User) (*models.Team, error) {
+	if len(members) == 0 {
+		return nil, apperr.Validation("members must not be empty")
+	}
+
+	var result *models.Team
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		if _, err := tx.GetTeam(teamName); err != nil {
+			return err
+		}
+
+		for _, member := range members {
+			if member.UserId == "" {
+				return apperr.Validation("user_id must not be empty")
+			}
+
+			member.TeamName = teamName
+			if member.ReviewWeight == 0 {
+				member.ReviewWeight = 1
+			}
+
+			// Пользователь, ранее удалённый из команды, остаётся в системе без команды:
+			// его возвращаем, а не создаём заново.
+			existing, err := tx.GetUser(member.UserId)
+			reattached := err == nil && existing.TeamName == ""
+			switch {
+			case reattached:
+				err = tx.ReattachUser(member)
+			case errors.Is(err, apperr.ErrNotFound):
+				err = tx.CreateUser(member)
+			case err == nil:
+				err = apperr.AlreadyExists("USER_EXISTS", "user already exists")
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := rs.audit(tx, models.AuditTeamMemberAdded, models.AuditEntityTeam, teamName, map[string]any{
+				"user_id":    member.UserId,
+				"reattached": reattached,
+			}); err != nil {
+				return err
+			}
+		}
+
+		team, err := tx.GetTeam(teamName)
+		if err != nil {
+			return err
+		}
+		result = team
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// RemoveTeamMember удаляет участника из команды и деактивирует его; пользователь
+// и его прошлые назначения остаются в системе. Открытые ревью
+// участника в режиме reassign передаются другим участникам команды, в режиме block
+// удаление отклоняется. Автор открытых PR не удаляется ни в каком режиме.
+func (rs *ReviewService) RemoveTeamMember(teamName, userID, openReviews string) (*models.TeamChangeReport, error) {
+	openReviews, err := openReviewsMode(openReviews)
+	if err != nil {
+		return nil, err
+	}
+	report := newTeamChangeReport(teamName)
+
+	err = rs.repo.Transaction(func(tx repository.Store) error {
+		user, err := tx.GetUser(userID)
+		if err != nil {
+			return err
+		}
+		if user.TeamName != teamName {
+			return apperr.NotFound("user is not a member of this team")
+		}
+
+		if err := rs.releaseOpenReviews(tx, []string{userID}, openReviews, "member_removed", report); err != nil {
+			return err
+		}
+
+		if err := tx.DetachUser(userID); err != nil {
+			return err
+		}
+		report.RemovedUserIDs = append(report.RemovedUserIDs, userID)
+
+		return rs.audit(tx, models.AuditTeamMemberRemoved, models.AuditEntityTeam, teamName, map[string]any{
+			"user_id":      userID,
+			"open_reviews": openReviews,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.ReviewersReassigned("member_removed", len(report.Replacements))
+
+	return report, nil
+}
+
+func (rs *ReviewService) RenameTeam(oldName, newName string) (*models.Team, error) {
+	if newName == "" {
+		return nil, apperr.Validation("new_team_name must not be empty")
+	}
+
+	var result *models.Team
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		if err := tx.RenameTeam(oldName, newName); err != nil {
+			return err
+		}
+
+		if err := rs.audit(tx, models.AuditTeamRenamed, models.AuditEntityTeam, oldName, map[string]any{
+			"new_team_name": newName,
+		}); err != nil {
+			return err
+		}
+
+		team, err := tx.GetTeam(newName)
+		if err != nil {
+			return err
+		}
+		result = team
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// DeleteTeam удаляет команду; её участники обрабатываются по тем же правилам,
+// что и в RemoveTeamMember.
+func (rs *ReviewService) DeleteTeam(teamName, openReviews string) (*models.TeamChangeReport, error) {
+	openReviews, err := openReviewsMode(openReviews)
+	if err != nil {
+		return nil, err
+	}
+	report := newTeamChangeReport(teamName)
+
+	err = rs.repo.Transaction(func(tx repository.Store) error {
+		team, err := tx.GetTeam(teamName)
+		if err != nil {
+			return err
+		}
+
+		memberIDs := userIDs(team.Members)
+		if err := rs.releaseOpenReviews(tx, memberIDs, openReviews, "team_deleted", report); err != nil {
+			return err
+		}
+
+		if err := tx.DeleteTeam(teamName); err != nil {
+			return err
+		}
+		report.RemovedUserIDs = append(report.RemovedUserIDs, memberIDs...)
+
+		return rs.audit(tx, models.AuditTeamDeleted, models.AuditEntityTeam, teamName, map[string]any{
+			"members":      memberIDs,
+			"open_reviews": openReviews,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.ReviewersReassigned("team_deleted", len(report.Replacements))
+
+	return report, nil
+}
+
+// releaseOpenReviews готовит уход участников leaving из команды: проверяет, что они
+// не авторы открытых PR, и либо отклоняет операцию при открытых ревью (block),
+// либо заменяет их оставшимися активными участниками команды автора каждого PR (reassign).
+func (rs *ReviewService) releaseOpenReviews(tx repository.Store, leaving []string, openReviews, reason string, report *models.TeamChangeReport) error {
+	authored, err := tx.GetOpenPRsByAuthors(leaving)
+	if err != nil {
+		return err
+	}
+	if len(authored) > 0 {
+		return apperr.Conflict("HAS_OPEN_PULL_REQUESTS", "leaving members are authors of open PRs").
+			WithDetails(prIDs(authored)...)
+	}
+
+	reviewed, err := tx.GetOpenPRsByReviewers(leaving)
+	if err != nil {
+		return err
+	}
+	if len(reviewed) == 0 {
+		return nil
+	}
+	if openReviews == models.OpenReviewsBlock {
+		return apperr.Conflict("HAS_OPEN_REVIEWS", "leaving members are assigned to open PRs").
+			WithDetails(prIDs(reviewed)...)
+	}
+
+	locked, err := lockOpenPRs(tx, reviewed)
+	if err != nil {
+		return err
+	}
+
+	pools := map[string][]models.User{}
+	for _, pr := range locked {
+		team, remaining, err := rs.replacementPool(tx, pr, leaving, pools)
+		if err != nil {
+			return err
+		}
+
+		replaced, removed, err := rs.replaceLeavingReviewers(tx, team, remaining, pr, leaving, reason)
+		if err != nil {
+			return err
+		}
+		if len(replaced) == 0 && len(removed) == 0 {
+			continue
+		}
+
+		if err := tx.UpdatePR(pr); err != nil {
+			return err
+		}
+		report.ChangedPullRequests = append(report.ChangedPullRequests, pr.PullRequestID)
+		report.Replacements = append(report.Replacements, replaced...)
+		report.RemovedWithoutReplacement = append(report.RemovedWithoutReplacement, removed...)
+	}
+	return nil
+}
+
+// openReviewsMode проверяет режим обработки открытых ревью; по умолчанию block.
+func openReviewsMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return models.OpenReviewsBlock, nil
+	case models.OpenReviewsBlock, models.OpenReviewsReassign:
+		return mode, nil
+	default:
+		return "", apperr.Validation("open_reviews must be one of block, reassign")
+	}
+}
+
+func newTeamChangeReport(teamName string) *models.TeamChangeReport {
+	return &models.TeamChangeReport{
+		TeamName:                  teamName,
+		RemovedUserIDs:            []string{},
+		ChangedPullRequests:       []string{},
+		Replacements:              []models.ReviewerReplacement{},
+		RemovedWithoutReplacement: []models.RemovedReviewer{},
+	}
+}
+
+func prIDs(prs []models.PullRequest) []string {
+	ids := make([]string, len(prs))
+	for i, pr := range prs {
+		ids[i] = pr.PullRequestID
+	}
+	return ids
+}
+
+// MoveUserToTeam переводит пользователя в другую команду. Если reassignOpenReviews,
+// его открытые ревью в PR старой команды передаются оставшимся участникам старой команды,
+// иначе назначения сохраняются.
+func (rs *ReviewService) MoveUserToTeam(userID, teamName string, reassignOpenReviews bool) (*models.User, []models.ReviewerReplacement, []models.RemovedReviewer, error) {
+	var result *models.User
+	replacements := []models.ReviewerReplacement{}
+	removals := []models.RemovedReviewer{}
+
+	err := rs.repo.Transaction(func(tx repository.Store) error {
+		user, err := tx.GetUser(userID)
+		if err != nil {
+			return err
+		}
+
+		oldTeamName := user.TeamName
+		if oldTeamName == teamName {
+			result = user
+			return nil
+		}
+
+		if reassignOpenReviews {
+			replacements, removals, err = rs.releaseTeamReviews(tx, oldTeamName, userID)
+			if err != nil {
+				return err
+			}
+		}
+
+		user, err = tx.UpdateUserTeam(userID, teamName)
+		if err != nil {
+			return err
+		}
+
+		if err := rs.audit(tx, models.AuditUserMoved, models.AuditEntityUser, userID, map[string]any{
+			"from_team":             oldTeamName,
+			"to_team":               teamName,
+			"reassign_open_reviews": reassignOpenReviews,
+		}); err != nil {
+			return err
+		}
+
+		result = user
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	metrics.ReviewersReassigned("moved_team", len(replacements))
+
+	return result, replacements, removals, nil
+}
+
+// releaseTeamReviews снимает userID с открытых PR, авторы которых состоят в teamName,
+// подбирая замену среди остальных активных участников этой команды.
+func (rs *ReviewService) releaseTeamReviews(tx repository.Store, teamName, userID string) ([]models.ReviewerReplacement, []models.RemovedReviewer, error) {
+	replacements := []models.ReviewerReplacement{}
+	removals := []models.RemovedReviewer{}
+
+	prs, err := tx.GetOpenPRsByReviewers([]string{userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(prs) == 0 {
+		return replacements, removals, nil
+	}
+
+	team, err := tx.GetTeam(teamName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	members, err := tx.GetActiveTeamMembers(teamName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	locked, err := lockOpenPRs(tx, prs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, pr := range locked {
+		author, err := tx.GetUser(pr.AuthorID)
+		if errors.Is(err, apperr.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if author.TeamName != teamName {
+			continue
+		}
+
+		replaced, removed, err := rs.replaceLeavingReviewers(tx, team, members, pr, []string{userID}, "moved_team")
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(replaced) == 0 && len(removed) == 0 {
+			continue
+		}
+
+		if err := tx.UpdatePR(pr); err != nil {
+			return nil, nil, err
+		}
+		replacements = append(replacements, replaced...)
+		removals = append(removals, removed...)
+	}
+	return replacements, removals, nil
+}